@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type Alert struct {
+	Expr      string
+	Op        string
+	Threshold float64
+	Interval  int
+	For       int
+	Channels  []string
+	compute   Compute
+	timer     *time.Timer
+	pending   time.Time
+	firing    bool
+	value     float64
+	failing   time.Time
+	unknown   bool
+	err       error
+	startErr  error
+	lock      sync.Mutex
+}
+
+func (a *Alert) Start(name string) error {
+	err := a.start(name)
+	if err != nil {
+		a.lock.Lock()
+		a.startErr = err
+		a.lock.Unlock()
+	}
+	return err
+}
+
+func (a *Alert) start(name string) error {
+	var err error
+	a.compute, err = Decode(a.Expr)
+	if err != nil {
+		return err
+	}
+	if _, err = a.check(0); err != nil {
+		return err
+	}
+	if a.Interval <= 0 {
+		a.Interval = 60
+	}
+	go func() {
+		a.timer = time.NewTimer(time.Second * time.Duration(a.Interval))
+		for _ = range a.timer.C {
+			a.timer.Reset(time.Second * time.Duration(a.Interval))
+			a.evaluate(name)
+		}
+	}()
+	return nil
+}
+
+func (a *Alert) check(value float64) (bool, error) {
+	switch a.Op {
+	case "":
+		return value != 0, nil
+	case "<":
+		return value < a.Threshold, nil
+	case "<=":
+		return value <= a.Threshold, nil
+	case ">":
+		return value > a.Threshold, nil
+	case ">=":
+		return value >= a.Threshold, nil
+	case "==":
+		return value == a.Threshold, nil
+	case "!=":
+		return value != a.Threshold, nil
+	}
+	return false, fmt.Errorf("Unknown alert operator %s", a.Op)
+}
+
+func (a *Alert) evaluate(name string) {
+	value, err := RunCompute(a.compute)
+	if err != nil {
+		log.Printf("Error computing alert %s: %s", name, err)
+	}
+	for _, notification := range a.update(name, value, err) {
+		SendToChannels(a.Channels, "%s", notification)
+	}
+}
+
+func (a *Alert) update(name string, value float64, err error) []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	notifications := []string{}
+	if err != nil {
+		a.err = err
+		if a.failing.IsZero() {
+			a.failing = time.Now()
+		}
+		if !a.unknown && time.Since(a.failing) >= time.Second*time.Duration(a.For) {
+			a.unknown = true
+			notifications = append(notifications, fmt.Sprintf("[%s] UNKNOWN %s: %s (%s)", Config.Name, name, a.Condition(), err))
+		}
+		return notifications
+	}
+	a.err = nil
+	a.failing = time.Time{}
+	if a.unknown {
+		a.unknown = false
+		notifications = append(notifications, fmt.Sprintf("[%s] RECOVERED %s: %s = %v", Config.Name, name, a.Expr, value))
+	}
+	a.value = value
+	triggered, _ := a.check(value)
+	if !triggered {
+		a.pending = time.Time{}
+		if a.firing {
+			a.firing = false
+			notifications = append(notifications, fmt.Sprintf("[%s] RESOLVED %s: %s = %v", Config.Name, name, a.Expr, value))
+		}
+		return notifications
+	}
+	if a.pending.IsZero() {
+		a.pending = time.Now()
+	}
+	if !a.firing && time.Since(a.pending) >= time.Second*time.Duration(a.For) {
+		a.firing = true
+		notifications = append(notifications, fmt.Sprintf("[%s] FIRING %s: %s (value %v)", Config.Name, name, a.Condition(), value))
+	}
+	return notifications
+}
+
+func (a *Alert) Condition() string {
+	if a.Op == "" {
+		return a.Expr
+	}
+	return fmt.Sprintf("%s %s %v", a.Expr, a.Op, a.Threshold)
+}
+
+func (a *Alert) Value() float64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.value
+}
+
+func (a *Alert) Status() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	switch {
+	case a.startErr != nil:
+		return fmt.Sprintf("not running: %s", a.startErr)
+	case a.unknown:
+		return fmt.Sprintf("unknown: %s", a.err)
+	case a.firing:
+		return "firing"
+	case !a.pending.IsZero():
+		return "pending"
+	}
+	return "ok"
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	. "github.com/andyleap/parser"
 )
@@ -57,6 +62,22 @@ func (t ComputeTerm) Run(values map[string]float64) (float64, error) {
 			v *= opv
 		case "/":
 			v /= opv
+		case "<":
+			v = computeBool(v < opv)
+		case "<=":
+			v = computeBool(v <= opv)
+		case ">":
+			v = computeBool(v > opv)
+		case ">=":
+			v = computeBool(v >= opv)
+		case "==":
+			v = computeBool(v == opv)
+		case "!=":
+			v = computeBool(v != opv)
+		case "&&":
+			v = computeBool(v != 0 && opv != 0)
+		case "||":
+			v = computeBool(v != 0 || opv != 0)
 		}
 	}
 	return v, nil
@@ -70,6 +91,254 @@ func (t ComputeTerm) GetVars() []*ComputeVariableFactor {
 	return vars
 }
 
+func computeBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type ComputeNot struct {
+	Expr Compute
+}
+
+func (n ComputeNot) Run(values map[string]float64) (float64, error) {
+	v, err := n.Expr.Run(values)
+	if err != nil {
+		return 0, err
+	}
+	return computeBool(v == 0), nil
+}
+
+func (n ComputeNot) GetVars() []*ComputeVariableFactor {
+	return n.Expr.GetVars()
+}
+
+type ComputeTernary struct {
+	Cond  Compute
+	True  Compute
+	False Compute
+}
+
+func (t ComputeTernary) Run(values map[string]float64) (float64, error) {
+	c, err := t.Cond.Run(values)
+	if err != nil {
+		return 0, err
+	}
+	if c != 0 {
+		return t.True.Run(values)
+	}
+	return t.False.Run(values)
+}
+
+func (t ComputeTernary) GetVars() []*ComputeVariableFactor {
+	vars := t.Cond.GetVars()
+	vars = append(vars, t.True.GetVars()...)
+	vars = append(vars, t.False.GetVars()...)
+	return vars
+}
+
+type ComputeFunctionDef struct {
+	MinArgs int
+	MaxArgs int
+	Run     func(args []float64) float64
+}
+
+var computeFunctions = map[string]ComputeFunctionDef{
+	"abs": {1, 1, func(args []float64) float64 {
+		return math.Abs(args[0])
+	}},
+	"floor": {1, 1, func(args []float64) float64 {
+		return math.Floor(args[0])
+	}},
+	"ceil": {1, 1, func(args []float64) float64 {
+		return math.Ceil(args[0])
+	}},
+	"round": {1, 2, func(args []float64) float64 {
+		if len(args) > 1 {
+			scale := math.Pow(10, math.Floor(args[1]))
+			return math.Floor(args[0]*scale+0.5) / scale
+		}
+		return math.Floor(args[0] + 0.5)
+	}},
+	"min": {1, 0, func(args []float64) float64 {
+		v := args[0]
+		for _, arg := range args[1:] {
+			v = math.Min(v, arg)
+		}
+		return v
+	}},
+	"max": {1, 0, func(args []float64) float64 {
+		v := args[0]
+		for _, arg := range args[1:] {
+			v = math.Max(v, arg)
+		}
+		return v
+	}},
+	"clamp": {3, 3, func(args []float64) float64 {
+		return math.Max(args[1], math.Min(args[2], args[0]))
+	}},
+	"if": {3, 3, func(args []float64) float64 {
+		if args[0] != 0 {
+			return args[1]
+		}
+		return args[2]
+	}},
+}
+
+type ComputeFunction struct {
+	Name string
+	Args []Compute
+}
+
+func (f ComputeFunction) String() string {
+	args := []string{}
+	for _, arg := range f.Args {
+		args = append(args, fmt.Sprint(arg))
+	}
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(args, ", "))
+}
+
+func (f ComputeFunction) Check() error {
+	def, ok := computeFunctions[f.Name]
+	if !ok {
+		return fmt.Errorf("Unknown function %s", f.Name)
+	}
+	if len(f.Args) < def.MinArgs || (def.MaxArgs > 0 && len(f.Args) > def.MaxArgs) {
+		return fmt.Errorf("Wrong number of arguments to %s", f.Name)
+	}
+	return nil
+}
+
+func (f ComputeFunction) Run(values map[string]float64) (float64, error) {
+	args := make([]float64, len(f.Args))
+	for i, arg := range f.Args {
+		v, err := arg.Run(values)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return computeFunctions[f.Name].Run(args), nil
+}
+
+func (f ComputeFunction) GetVars() []*ComputeVariableFactor {
+	vars := []*ComputeVariableFactor{}
+	for _, arg := range f.Args {
+		vars = append(vars, arg.GetVars()...)
+	}
+	return vars
+}
+
+type ComputeHistoryFunctionDef struct {
+	MinSamples int
+	Samples    int
+	Run        func(samples []float64, times []time.Time) (float64, error)
+}
+
+func counterDelta(samples []float64) float64 {
+	first, last := samples[0], samples[len(samples)-1]
+	if last < first {
+		return last
+	}
+	return last - first
+}
+
+var computeHistoryFunctions = map[string]ComputeHistoryFunctionDef{
+	"delta": {2, 2, func(samples []float64, times []time.Time) (float64, error) {
+		return counterDelta(samples), nil
+	}},
+	"rate": {2, 2, func(samples []float64, times []time.Time) (float64, error) {
+		elapsed := times[len(times)-1].Sub(times[0]).Seconds()
+		if elapsed <= 0 {
+			return 0, fmt.Errorf("Samples cover no time")
+		}
+		return counterDelta(samples) / elapsed, nil
+	}},
+	"avg": {1, 0, func(samples []float64, times []time.Time) (float64, error) {
+		total := 0.0
+		for _, sample := range samples {
+			total += sample
+		}
+		return total / float64(len(samples)), nil
+	}},
+}
+
+type ComputeHistoryFunction struct {
+	Name     string
+	Variable ComputeVariableFactor
+	Samples  int
+}
+
+func newComputeHistoryFunction(name string, args []Compute) (ComputeHistoryFunction, error) {
+	f := ComputeHistoryFunction{
+		Name:    name,
+		Samples: computeHistoryFunctions[name].Samples,
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return f, fmt.Errorf("Wrong number of arguments to %s", name)
+	}
+	vf, ok := args[0].(ComputeVariableFactor)
+	if !ok {
+		return f, fmt.Errorf("First argument to %s must be a monitor variable", name)
+	}
+	f.Variable = vf
+	if len(args) > 1 {
+		nf, ok := args[1].(ComputeNumberFactor)
+		if !ok || nf.Number < float64(computeHistoryFunctions[name].MinSamples) {
+			return f, fmt.Errorf("Second argument to %s must be a number of samples of at least %d", name, computeHistoryFunctions[name].MinSamples)
+		}
+		f.Samples = int(nf.Number)
+	}
+	return f, nil
+}
+
+func (f ComputeHistoryFunction) String() string {
+	if f.Samples == 0 {
+		return fmt.Sprintf("%s(%s)", f.Name, f.Variable)
+	}
+	return fmt.Sprintf("%s(%s, %d)", f.Name, f.Variable, f.Samples)
+}
+
+func (f ComputeHistoryFunction) Run(values map[string]float64) (float64, error) {
+	monitor, ok := Config.Monitors[f.Variable.Monitor]
+	if !ok {
+		return 0, fmt.Errorf("Can't find monitor %s", f.Variable.Monitor)
+	}
+	vt, ok := monitor.track.Variable(f.Variable.Variable)
+	if !ok {
+		return 0, fmt.Errorf("Variable %s is not tracked, enable tracking with `monitor %s track %s <items>`", f.Variable, f.Variable.Monitor, f.Variable.Variable)
+	}
+	samples := []float64{}
+	times := []time.Time{}
+	for _, sample := range vt.Data {
+		if !sample.Valid {
+			continue
+		}
+		if fval, ok := ComputeFloat(sample.Value); ok {
+			samples = append(samples, fval)
+			times = append(times, sample.Time)
+		}
+	}
+	if f.Samples > 0 && len(samples) > f.Samples {
+		samples = samples[len(samples)-f.Samples:]
+		times = times[len(times)-f.Samples:]
+	}
+	def := computeHistoryFunctions[f.Name]
+	if len(samples) < def.MinSamples {
+		return 0, fmt.Errorf("Not enough history for %s, have %d samples and need %d", f, len(samples), def.MinSamples)
+	}
+	val, err := def.Run(samples, times)
+	if err != nil {
+		return 0, fmt.Errorf("Error computing %s: %s", f, err)
+	}
+	return val, nil
+}
+
+func (f ComputeHistoryFunction) GetVars() []*ComputeVariableFactor {
+	return []*ComputeVariableFactor{}
+}
+
 type ComputeExprFactor struct {
 	Expr Compute
 }
@@ -111,14 +380,23 @@ type ComputeVariableFactor struct {
 	Variable string
 }
 
+var computeVariableName = regexp.MustCompile("^[a-zA-Z0-9_.]+$")
+
+func computeKey(monitor, variable string) string {
+	return monitor + "." + variable
+}
+
 func (vf ComputeVariableFactor) String() string {
-	return fmt.Sprintf("%s.%s", vf.Monitor, vf.Variable)
+	if !computeVariableName.MatchString(vf.Variable) {
+		return fmt.Sprintf("%s[%s]", vf.Monitor, strconv.Quote(vf.Variable))
+	}
+	return computeKey(vf.Monitor, vf.Variable)
 }
 
 func (vf ComputeVariableFactor) Run(values map[string]float64) (float64, error) {
-	m, ok := values[fmt.Sprintf("%s.%s", vf.Monitor, vf.Variable)]
+	m, ok := values[computeKey(vf.Monitor, vf.Variable)]
 	if !ok {
-		return 0, fmt.Errorf("Can't find variable %s.%s", vf.Monitor, vf.Variable)
+		return 0, fmt.Errorf("Can't find variable %s", vf)
 	}
 	return m, nil
 }
@@ -132,6 +410,8 @@ var (
 )
 
 func init() {
+	ws := Mult(0, 0, Set(" \t"))
+
 	number := And(Mult(0, 1, Lit("-")), Mult(1, 0, Set("0-9")), Mult(0, 1, And(Lit("."), Mult(0, 0, Set("0-9")))))
 	number.Node(func(m Match) (Match, error) {
 		v, err := strconv.ParseFloat(String(m), 64)
@@ -141,52 +421,70 @@ func init() {
 		return ComputeNumberFactor{Number: v}, nil
 	})
 
-	variable := And(
-		Tag("Monitor", Mult(1, 0, Set("a-zA-Z"))),
-		Lit("."),
-		Tag("Variable", Mult(1, 0, Set("a-zA-Z"))))
-
-	variable.Node(func(m Match) (Match, error) {
-		mtag := GetTag(m, "Monitor")
-		vtag := GetTag(m, "Variable")
-		return ComputeVariableFactor{
-			Monitor:  String(mtag.Match),
-			Variable: String(vtag.Match),
-		}, nil
-	})
-
 	expr := &Grammar{}
 
-	parenexpr := And(Lit("("), Tag("Expr", expr), Lit(")"))
+	args := And(ws, Tag("Start", expr), ws, Tag("Rest", Mult(0, 0, And(Lit(","), ws, Tag("Arg", expr), ws))))
+	call := And(Lit("("), Tag("Args", Mult(0, 1, args)), Lit(")"))
+	ident := And(Set("a-zA-Z_"), Mult(0, 0, Set("a-zA-Z0-9_")))
+	field := And(Lit("."), Tag("Variable", Mult(1, 0, Set("a-zA-Z0-9_."))))
+
+	quoted := Or(
+		And(Lit("\""), Tag("Text", Mult(1, 0, Set(" !#-~"))), Lit("\"")),
+		And(Lit("'"), Tag("Text", Mult(1, 0, Set(" -&(-~"))), Lit("'")))
+	quoted.Node(func(m Match) (Match, error) {
+		return MatchString(String(GetTag(m, "Text").Match)), nil
+	})
+	index := And(Lit("["), ws, Tag("Variable", quoted), ws, Lit("]"))
+
+	named := And(Tag("Name", ident), Tag("Rest", Or(call, field, index)))
+	named.Node(func(m Match) (Match, error) {
+		name := String(GetTag(m, "Name").Match)
+		rest := GetTag(m, "Rest").Match.(MatchTree)
+		if vtag := GetTag(rest, "Variable"); vtag != nil {
+			return ComputeVariableFactor{
+				Monitor:  name,
+				Variable: String(vtag.Match),
+			}, nil
+		}
+		f := ComputeFunction{Name: name}
+		for _, a := range GetTag(rest, "Args").Match.(MatchTree) {
+			f.Args = append(f.Args, GetTag(a, "Start").Match.(Compute))
+			for _, arg := range GetTag(a, "Rest").Match.(MatchTree) {
+				f.Args = append(f.Args, GetTag(arg, "Arg").Match.(Compute))
+			}
+		}
+		if _, ok := computeHistoryFunctions[name]; ok {
+			return newComputeHistoryFunction(name, f.Args)
+		}
+		if err := f.Check(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	})
+
+	parenexpr := And(Lit("("), ws, Tag("Expr", expr), ws, Lit(")"))
 	parenexpr.Node(func(m Match) (Match, error) {
 		etag := GetTag(m, "Expr")
 		return etag.Match, nil
 	})
 
-	factor := Or(parenexpr, variable, number)
+	factor := Or(parenexpr, named, number)
 
-	term := And(Tag("Start", factor), Tag("Ops", Mult(0, 0, And(Tag("Op", Set("*/")), Tag("Operand", factor)))))
-	term.Node(func(m Match) (Match, error) {
-		fmt.Println(m)
-		start := GetTag(m, "Start").Match.(Compute)
-		ops := []ComputeOperand{}
-		for _, op := range GetTag(m, "Ops").Match.(MatchTree) {
-			ops = append(ops, ComputeOperand{
-				Op:      string(GetTag(op, "Op").Match.(MatchString)),
-				Operand: GetTag(op, "Operand").Match.(Compute),
-			})
-		}
-		if len(ops) == 0 {
-			return start, nil
-		}
+	unary := &Grammar{}
+	not := And(Lit("!"), ws, Tag("Expr", unary))
+	not.Node(func(m Match) (Match, error) {
+		return ComputeNot{Expr: GetTag(m, "Expr").Match.(Compute)}, nil
+	})
+	neg := And(Lit("-"), ws, Tag("Expr", unary))
+	neg.Node(func(m Match) (Match, error) {
 		return ComputeTerm{
-			Start: start,
-			Ops:   ops,
+			Start: ComputeNumberFactor{Number: 0},
+			Ops:   []ComputeOperand{{Op: "-", Operand: GetTag(m, "Expr").Match.(Compute)}},
 		}, nil
 	})
+	unary.Set(Or(not, factor, neg))
 
-	expr.Set(And(Tag("Start", term), Tag("Ops", Mult(0, 0, And(Tag("Op", Set("-+")), Tag("Operand", term))))))
-	expr.Node(func(m Match) (Match, error) {
+	opsNode := func(m Match) (Match, error) {
 		start := GetTag(m, "Start").Match.(Compute)
 		ops := []ComputeOperand{}
 		for _, op := range GetTag(m, "Ops").Match.(MatchTree) {
@@ -202,16 +500,51 @@ func init() {
 			Start: start,
 			Ops:   ops,
 		}, nil
+	}
+
+	term := And(Tag("Start", unary), Tag("Ops", Mult(0, 0, And(ws, Tag("Op", Set("*/")), ws, Tag("Operand", unary)))))
+	term.Node(opsNode)
+
+	sum := And(Tag("Start", term), Tag("Ops", Mult(0, 0, And(ws, Tag("Op", Set("-+")), ws, Tag("Operand", term)))))
+	sum.Node(opsNode)
+
+	cmpOp := Or(Lit("<="), Lit(">="), Lit("=="), Lit("!="), Lit("<"), Lit(">"))
+	cmp := And(Tag("Start", sum), Tag("Ops", Mult(0, 1, And(ws, Tag("Op", cmpOp), ws, Tag("Operand", sum)))))
+	cmp.Node(opsNode)
+
+	and := And(Tag("Start", cmp), Tag("Ops", Mult(0, 0, And(ws, Tag("Op", Lit("&&")), ws, Tag("Operand", cmp)))))
+	and.Node(opsNode)
+
+	or := And(Tag("Start", and), Tag("Ops", Mult(0, 0, And(ws, Tag("Op", Lit("||")), ws, Tag("Operand", and)))))
+	or.Node(opsNode)
+
+	expr.Set(And(Tag("Cond", or), Tag("Branches", Mult(0, 1, And(ws, Lit("?"), ws, Tag("True", expr), ws, Lit(":"), ws, Tag("False", expr))))))
+	expr.Node(func(m Match) (Match, error) {
+		cond := GetTag(m, "Cond").Match.(Compute)
+		branches := GetTag(m, "Branches").Match.(MatchTree)
+		if len(branches) == 0 {
+			return cond, nil
+		}
+		return ComputeTernary{
+			Cond:  cond,
+			True:  GetTag(branches[0], "True").Match.(Compute),
+			False: GetTag(branches[0], "False").Match.(Compute),
+		}, nil
 	})
 
 	computeGrammar = expr
 }
 
 func Decode(expr string) (Compute, error) {
-	comp, err := computeGrammar.ParseString(expr)
+	expr = strings.TrimSpace(expr)
+	r := strings.NewReader(expr)
+	comp, err := computeGrammar.Parse(r)
 	if err != nil {
 		return nil, err
 	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("Unexpected input at position %d in %s", int(r.Size())-r.Len(), expr)
+	}
 	return comp.(Compute), nil
 }
 
@@ -220,17 +553,45 @@ type ComputedVariable struct {
 	Name    string
 }
 
-func RunComputeds(cvs []ComputedVariable) map[string]float64 {
+func NewComputedVariable(name string, expr string) (ComputedVariable, error) {
+	c, err := Decode(expr)
+	if err != nil {
+		return ComputedVariable{}, err
+	}
+	return ComputedVariable{
+		compute: c,
+		Name:    name,
+	}, nil
+}
+
+func ComputeFloat(val interface{}) (float64, bool) {
+	switch tt := val.(type) {
+	case float64:
+		return tt, true
+	case float32:
+		return float64(tt), true
+	case uint32:
+		return float64(tt), true
+	case uint64:
+		return float64(tt), true
+	case int32:
+		return float64(tt), true
+	case int64:
+		return float64(tt), true
+	}
+	return 0, false
+}
+
+func computeValues(cvars []*ComputeVariableFactor) (map[string]float64, error) {
 	vars := make(map[string]map[string]bool)
-	for _, cv := range cvs {
-		for _, v := range cv.compute.GetVars() {
-			mvars, ok := vars[v.Monitor]
-			if !ok {
-				mvars = make(map[string]bool)
-				vars[v.Monitor] = mvars
-			}
-			mvars[v.Variable] = true
+
+	for _, v := range cvars {
+		mvars, ok := vars[v.Monitor]
+		if !ok {
+			mvars = make(map[string]bool)
+			vars[v.Monitor] = mvars
 		}
+		mvars[v.Variable] = true
 	}
 
 	values := make(map[string]float64)
@@ -240,34 +601,40 @@ func RunComputeds(cvs []ComputedVariable) map[string]float64 {
 		for vn := range v {
 			vs = append(vs, vn)
 		}
-		vals := Config.Monitors[m].monitor.GetValues(vs)
+		monitor, ok := Config.Monitors[m]
+		if !ok {
+			return nil, fmt.Errorf("Can't find monitor %s", m)
+		}
+		vals := monitor.monitor.GetValues(vs)
 		for k, val := range vals {
-			var fval float64
-			switch tt := val.(type) {
-			case float64:
-				fval = tt
-			case float32:
-				fval = float64(tt)
-			case uint32:
-				fval = float64(tt)
-			case uint64:
-				fval = float64(tt)
-			case int32:
-				fval = float64(tt)
-			case int64:
-				fval = float64(tt)
-			default:
+			fval, ok := ComputeFloat(val)
+			if !ok {
 				continue
 			}
-			values[fmt.Sprintf("%s.%s", m, k)] = fval
+			values[computeKey(m, k)] = fval
 		}
 	}
 
+	return values, nil
+}
+
+func RunComputeds(cvs []ComputedVariable) map[string]float64 {
+	cvars := []*ComputeVariableFactor{}
+	for _, cv := range cvs {
+		cvars = append(cvars, cv.compute.GetVars()...)
+	}
+
 	vals := make(map[string]float64)
 
+	values, err := computeValues(cvars)
+	if err != nil {
+		log.Printf("Error computing variables: %s", err)
+		return vals
+	}
+
 	for _, cv := range cvs {
 		val, err := cv.compute.Run(values)
-		if err != nil {
+		if err == nil {
 			vals[cv.Name] = val
 		}
 	}
@@ -276,45 +643,9 @@ func RunComputeds(cvs []ComputedVariable) map[string]float64 {
 }
 
 func RunCompute(c Compute) (float64, error) {
-	vars := make(map[string]map[string]bool)
-
-	for _, v := range c.GetVars() {
-		mvars, ok := vars[v.Monitor]
-		if !ok {
-			mvars = make(map[string]bool)
-			vars[v.Monitor] = mvars
-		}
-		mvars[v.Variable] = true
-	}
-
-	values := make(map[string]float64)
-
-	for m, v := range vars {
-		vs := make([]string, 0)
-		for vn := range v {
-			vs = append(vs, vn)
-		}
-		vals := Config.Monitors[m].monitor.GetValues(vs)
-		for k, val := range vals {
-			var fval float64
-			switch tt := val.(type) {
-			case float64:
-				fval = tt
-			case float32:
-				fval = float64(tt)
-			case uint32:
-				fval = float64(tt)
-			case uint64:
-				fval = float64(tt)
-			case int32:
-				fval = float64(tt)
-			case int64:
-				fval = float64(tt)
-			default:
-				continue
-			}
-			values[fmt.Sprintf("%s.%s", m, k)] = fval
-		}
+	values, err := computeValues(c.GetVars())
+	if err != nil {
+		return 0, err
 	}
 
 	val, err := c.Run(values)
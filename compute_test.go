@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeRun(t *testing.T) {
+	values := map[string]float64{
+		"memory.MemFree":          400000,
+		"memory.MemTotal":         1000000,
+		"memory.Active(anon)":     7,
+		"mysql.Threads_connected": 4,
+		"disk.root.used_pct":      3,
+	}
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1+2*3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10-2-3", 5},
+		{"8/4/2", 1},
+		{" 2 <= 2 ", 1},
+		{"1 + 2 < 4", 1},
+		{"(1 < 2) == 1", 1},
+		{"0 || 1 && 0", 0},
+		{"1 || 0 && 0", 1},
+		{"!0 && !0", 1},
+		{"!1", 0},
+		{"-3 * 2", -6},
+		{"5 - -3", 8},
+		{"-memory.MemFree", -400000},
+		{"-(1 + 2)", -3},
+		{"1 ? 2 : 3", 2},
+		{"0 ? 2 : 3", 3},
+		{"0 ? 2 : 1 ? 4 : 5", 4},
+		{"1 < 2 ? 10 : 20", 10},
+		{"abs(-3)", 3},
+		{"min(3, 1, 2)", 1},
+		{"max(3,1,2)", 3},
+		{"round(2.567, 2)", 2.57},
+		{"clamp(15, 0, 10)", 10},
+		{"if(memory.MemFree > 1, 10, 20)", 10},
+		{"memory.MemFree / memory.MemTotal * 100", 40},
+		{"memory.MemFree < 500000 && mysql.Threads_connected > 2", 1},
+		{"disk.root.used_pct", 3},
+		{`memory["Active(anon)"]`, 7},
+		{`memory[ 'Active(anon)' ] + 1`, 8},
+		{`mysql["Threads_connected"] * 2`, 8},
+	}
+	for _, test := range tests {
+		c, err := Decode(test.expr)
+		if err != nil {
+			t.Errorf("Decode(%q): %s", test.expr, err)
+			continue
+		}
+		got, err := c.Run(values)
+		if err != nil {
+			t.Errorf("Run(%q): %s", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Run(%q) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"1 +",
+		"1<2<3",
+		"memory.MemFree garbage",
+		"memory.MemFree < 5OO000",
+		"x < 1 and y < 2",
+		"(1 + 2",
+		"1 ? 2",
+		"foo(1)",
+		"abs(1, 2)",
+		"clamp(1)",
+		"round()",
+		`memory["Active(anon)`,
+	}
+	for _, expr := range tests {
+		if _, err := Decode(expr); err == nil {
+			t.Errorf("Decode(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestRunMissingVariable(t *testing.T) {
+	c, err := Decode(`memory["Active(file)"] + 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Run(map[string]float64{}); err == nil {
+		t.Errorf("Run with missing variable succeeded, want error")
+	}
+}
+
+func TestHistoryFunctions(t *testing.T) {
+	start := time.Now()
+	times := []time.Time{start, start.Add(10 * time.Second)}
+	tests := []struct {
+		function string
+		samples  []float64
+		want     float64
+	}{
+		{"delta", []float64{100, 150}, 50},
+		{"rate", []float64{100, 150}, 5},
+		{"delta", []float64{1000, 30}, 30},
+		{"rate", []float64{1000, 30}, 3},
+		{"avg", []float64{100, 150}, 125},
+	}
+	for _, test := range tests {
+		got, err := computeHistoryFunctions[test.function].Run(test.samples, times)
+		if err != nil {
+			t.Errorf("%s(%v): %s", test.function, test.samples, err)
+		} else if got != test.want {
+			t.Errorf("%s(%v) = %v, want %v", test.function, test.samples, got, test.want)
+		}
+	}
+}
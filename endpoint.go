@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"log"
+	"strings"
 )
 
 var endpointDrivers = make(map[string]func(*json.RawMessage) Endpoint)
@@ -27,3 +29,43 @@ type MessageTarget interface {
 	IsPublic() bool
 	SendMessage(format string, args ...interface{})
 }
+
+func GetEndpoint(name string) Endpoint {
+	for _, endpointConfig := range Config.Endpoints {
+		if endpointConfig.e == nil {
+			continue
+		}
+		if endpointConfig.Name == name || (endpointConfig.Name == "" && endpointConfig.Driver == name) {
+			return endpointConfig.e
+		}
+	}
+	return nil
+}
+
+func SendToChannels(channels []string, format string, args ...interface{}) {
+	for _, channel := range channels {
+		var endpoints []Endpoint
+		if pos := strings.Index(channel, ":"); pos >= 0 {
+			e := GetEndpoint(channel[:pos])
+			if e == nil {
+				log.Printf("Unknown endpoint in channel target %s", channel)
+				continue
+			}
+			endpoints = append(endpoints, e)
+			channel = channel[pos+1:]
+		} else {
+			for _, endpointConfig := range Config.Endpoints {
+				if endpointConfig.e != nil {
+					endpoints = append(endpoints, endpointConfig.e)
+				}
+			}
+		}
+		for _, e := range endpoints {
+			target := e.GetChannel(channel)
+			if target == nil {
+				continue
+			}
+			target.SendMessage(format, args...)
+		}
+	}
+}
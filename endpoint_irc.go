@@ -48,17 +48,26 @@ func newEndpointIRC(options *json.RawMessage) *EndpointIRC {
 	e.conn.HandleFunc(irc.PRIVMSG, e.message)
 	e.conn.HandleFunc(irc.DISCONNECTED,
 		func(conn *irc.Conn, line *irc.Line) {
-			time.AfterFunc(time.Second*30, func() {
-				e.conn.Connect()
-			})
+			e.reconnect()
 		})
 	return e
 }
 
+func (ei *EndpointIRC) reconnect() {
+	time.AfterFunc(time.Second*30, func() {
+		err := ei.conn.Connect()
+		if err != nil {
+			log.Printf("Error while reconnecting IRC endpoint: %s", err)
+			ei.reconnect()
+		}
+	})
+}
+
 func (ei *EndpointIRC) Run() {
 	err := ei.conn.Connect()
 	if err != nil {
 		log.Printf("Error while connecting IRC endpoint: %s", err)
+		ei.reconnect()
 	}
 }
 
@@ -126,6 +135,9 @@ func (u *UserIRC) IsPublic() bool {
 }
 
 func (mt *MessageTargetIRC) SendMessage(format string, args ...interface{}) {
+	if !mt.ei.conn.Connected() {
+		return
+	}
 	mt.ei.conn.Privmsgf(mt.target, format, args...)
 }
 
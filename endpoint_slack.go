@@ -3,6 +3,9 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 
 	irc "github.com/fluffle/goirc/client"
 	"github.com/nlopes/slack"
@@ -23,6 +26,7 @@ type EndpointSlack struct {
 	ims      map[string]string
 	rtm      *slack.RTM
 	msgid    int
+	msgLock  sync.Mutex
 	handler  func(text string, source User, channel string, response MessageTarget)
 }
 
@@ -59,7 +63,11 @@ func newEndpointSlack(options *json.RawMessage) *EndpointSlack {
 
 func (es *EndpointSlack) Run() {
 	for _, channel := range es.Config.Channels {
-		schannel, _ := es.slack.JoinChannel(channel)
+		schannel, err := es.slack.JoinChannel(channel)
+		if err != nil {
+			log.Printf("Error joining slack channel %s: %s", channel, err)
+			continue
+		}
 		es.channels = append(es.channels, schannel)
 	}
 
@@ -119,6 +127,7 @@ func (es *EndpointSlack) GetUser(nick string) User {
 }
 
 func (es *EndpointSlack) GetChannel(channel string) MessageTarget {
+	channel = strings.TrimPrefix(channel, "#")
 	for _, schannel := range es.channels {
 		if schannel.Name == channel {
 			return &MessageTargetSlack{
@@ -135,6 +144,14 @@ func (es *EndpointSlack) HandleMessage(handler func(text string, source User, ch
 	es.handler = handler
 }
 
+func (es *EndpointSlack) send(msg *slack.OutgoingMessage) {
+	es.msgLock.Lock()
+	defer es.msgLock.Unlock()
+	msg.ID = es.msgid
+	es.msgid += 1
+	es.rtm.SendMessage(msg)
+}
+
 func (u *UserSlack) SendMessage(format string, args ...interface{}) {
 	msg := &slack.OutgoingMessage{}
 	var dm string
@@ -146,9 +163,7 @@ func (u *UserSlack) SendMessage(format string, args ...interface{}) {
 	msg.Channel = dm
 	msg.Text = fmt.Sprintf(format, args...)
 	msg.Type = slack.TYPE_MESSAGE
-	msg.ID = u.es.msgid
-	u.es.msgid += 1
-	u.es.rtm.SendMessage(msg)
+	u.es.send(msg)
 }
 
 func (u *UserSlack) HasRights() bool {
@@ -164,9 +179,7 @@ func (mt *MessageTargetSlack) SendMessage(format string, args ...interface{}) {
 	msg.Channel = mt.target
 	msg.Text = fmt.Sprintf(format, args...)
 	msg.Type = slack.TYPE_MESSAGE
-	msg.ID = mt.es.msgid
-	mt.es.msgid += 1
-	mt.es.rtm.SendMessage(msg)
+	mt.es.send(msg)
 }
 
 func (mt *MessageTargetSlack) IsPublic() bool {
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Exporter interface {
+	Export([]ExportSample) error
+}
+
+type ExportSample struct {
+	Name     string
+	Server   string
+	Monitor  string
+	Variable string
+	Value    float64
+	Time     time.Time
+}
+
+var exporterDrivers = make(map[string]func(*json.RawMessage) Exporter)
+
+func AddExporterDriver(exporter string, constructor func(*json.RawMessage) Exporter) {
+	exporterDrivers[exporter] = constructor
+}
+
+type ExporterConfig struct {
+	Driver   string
+	Options  *json.RawMessage
+	Interval int
+	Template string
+	exporter Exporter
+	samples  []ExportSample
+	lock     sync.Mutex
+}
+
+var exportInvalidChars = regexp.MustCompile("[^a-zA-Z0-9_.-]")
+
+func (ec *ExporterConfig) Start() {
+	if ec.Interval <= 0 {
+		ec.Interval = 10
+	}
+	if ec.Template == "" {
+		ec.Template = "srvbot.{server}.{monitor}.{variable}"
+	}
+	go func() {
+		for _ = range time.Tick(time.Second * time.Duration(ec.Interval)) {
+			ec.Flush()
+		}
+	}()
+}
+
+func (ec *ExporterConfig) Add(monitor string, variable string, sample MonitorSample) {
+	point, ok := sample.Rollup()
+	if !ok {
+		return
+	}
+	name := strings.NewReplacer(
+		"{server}", exportInvalidChars.ReplaceAllString(Config.Name, "_"),
+		"{monitor}", exportInvalidChars.ReplaceAllString(monitor, "_"),
+		"{variable}", exportInvalidChars.ReplaceAllString(variable, "_"),
+	).Replace(ec.Template)
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+	ec.samples = append(ec.samples, ExportSample{
+		Name:     name,
+		Server:   Config.Name,
+		Monitor:  monitor,
+		Variable: variable,
+		Value:    point.Avg,
+		Time:     point.Time,
+	})
+}
+
+func (ec *ExporterConfig) Flush() {
+	ec.lock.Lock()
+	samples := ec.samples
+	ec.samples = nil
+	ec.lock.Unlock()
+	if len(samples) == 0 {
+		return
+	}
+	err := ec.exporter.Export(samples)
+	if err != nil {
+		log.Printf("Error exporting to %s: %s", ec.Driver, err)
+	}
+}
+
+func exportPackets(lines []string, size int) []string {
+	packets := []string{}
+	packet := ""
+	for _, line := range lines {
+		if packet != "" && len(packet)+len(line)+1 > size {
+			packets = append(packets, packet)
+			packet = ""
+		}
+		if packet != "" {
+			packet += "\n"
+		}
+		packet += line
+	}
+	if packet != "" {
+		packets = append(packets, packet)
+	}
+	return packets
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+func init() {
+	AddExporterDriver("graphite", func(options *json.RawMessage) Exporter {
+		e := &GraphiteExporter{}
+		if options != nil {
+			json.Unmarshal(*options, &e)
+		}
+		if e.Protocol == "" {
+			e.Protocol = "tcp"
+		}
+		if e.Address == "" {
+			e.Address = "localhost:2003"
+		}
+		return e
+	})
+}
+
+type GraphiteExporter struct {
+	Address  string
+	Protocol string
+}
+
+func (e *GraphiteExporter) Export(samples []ExportSample) error {
+	conn, err := net.DialTimeout(e.Protocol, e.Address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	lines := []string{}
+	for _, sample := range samples {
+		lines = append(lines, fmt.Sprintf("%s %s %d", sample.Name, strconv.FormatFloat(sample.Value, 'f', -1, 64), sample.Time.Unix()))
+	}
+	if e.Protocol == "udp" {
+		for _, packet := range exportPackets(lines, 1024) {
+			if _, err := conn.Write([]byte(packet + "\n")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, line := range lines {
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	AddExporterDriver("influxdb", func(options *json.RawMessage) Exporter {
+		e := &InfluxDBExporter{}
+		if options != nil {
+			json.Unmarshal(*options, &e)
+		}
+		if e.URL == "" && e.Address == "" {
+			e.URL = "http://localhost:8086/write?db=srvbot"
+		}
+		return e
+	})
+}
+
+type InfluxDBExporter struct {
+	URL     string
+	Address string
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+func (e *InfluxDBExporter) Export(samples []ExportSample) error {
+	lines := []string{}
+	for _, sample := range samples {
+		lines = append(lines, fmt.Sprintf("%s,server=%s,monitor=%s,variable=%s value=%s %d",
+			influxEscaper.Replace(sample.Name),
+			influxEscaper.Replace(sample.Server),
+			influxEscaper.Replace(sample.Monitor),
+			influxEscaper.Replace(sample.Variable),
+			strconv.FormatFloat(sample.Value, 'f', -1, 64),
+			sample.Time.UnixNano()))
+	}
+	if e.URL == "" {
+		conn, err := net.Dial("udp", e.Address)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		for _, packet := range exportPackets(lines, 1024) {
+			if _, err := conn.Write([]byte(packet + "\n")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(e.URL, "text/plain", bytes.NewBufferString(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("InfluxDB returned %s", resp.Status)
+	}
+	return nil
+}
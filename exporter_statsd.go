@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+func init() {
+	AddExporterDriver("statsd", func(options *json.RawMessage) Exporter {
+		e := &StatsDExporter{}
+		if options != nil {
+			json.Unmarshal(*options, &e)
+		}
+		if e.Address == "" {
+			e.Address = "localhost:8125"
+		}
+		return e
+	})
+}
+
+type StatsDExporter struct {
+	Address string
+}
+
+func (e *StatsDExporter) Export(samples []ExportSample) error {
+	lines := []string{}
+	for _, sample := range samples {
+		if sample.Value < 0 {
+			lines = append(lines, fmt.Sprintf("%s:0|g", sample.Name))
+		}
+		lines = append(lines, fmt.Sprintf("%s:%s|g", sample.Name, strconv.FormatFloat(sample.Value, 'f', -1, 64)))
+	}
+	conn, err := net.Dial("udp", e.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, packet := range exportPackets(lines, 512) {
+		if _, err := conn.Write([]byte(packet)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
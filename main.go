@@ -29,9 +29,15 @@ type ConfigData struct {
 	Commands  map[string]*Command
 	Logs      map[string]*Log
 	Monitors  map[string]*MonitorConfig
+	Computed  map[string]string
+	Alerts    map[string]*Alert
+	Storage   *StorageConfig
+	Metrics   *MetricsConfig
+	Exporters []*ExporterConfig
 }
 
 type EndpointConfig struct {
+	Name    string
 	Driver  string
 	Options *json.RawMessage
 	e       Endpoint
@@ -43,19 +49,20 @@ type Command struct {
 }
 
 type Log struct {
-	File  string
-	Regex string
-	//	Live bool
-	Keep int
-	//	Channels []string
-	lines []*tail.Line
+	File     string
+	Regex    string
+	Live     bool
+	Keep     int
+	Channels []string
+	lines    []*tail.Line
 }
 
 type MonitorConfig struct {
-	Driver  string
-	Options *json.RawMessage
-	monitor Monitor
-	track   *MonitorTrack
+	Driver   string
+	Options  *json.RawMessage
+	Interval int
+	monitor  Monitor
+	track    *MonitorTrack
 }
 
 var Config ConfigData
@@ -83,6 +90,7 @@ func main() {
 
 			if err != nil {
 				log.Printf("Error tailing file: %s", err)
+				return
 			}
 			var filter *regexp.Regexp
 			if logConfig.Regex != "" {
@@ -101,21 +109,59 @@ func main() {
 					if len(logConfig.lines) > logConfig.Keep {
 						logConfig.lines = logConfig.lines[len(logConfig.lines)-logConfig.Keep:]
 					}
-					/*if logConfig.Live {
-						for _, channel := range logConfig.Channels {
-							c.Privmsg(channel, line.Text)
-						}
-					}*/
+					if logConfig.Live {
+						SendToChannels(logConfig.Channels, "[%s] %s", name, line.Text)
+					}
 				}
 			}
 
 		}(name, logConfig)
 	}
-	for _, monitorConfig := range Config.Monitors {
+	if Config.Storage != nil {
+		storage, err = newStorage(Config.Storage)
+		if err != nil {
+			log.Fatalf("Error opening storage %s\n", err)
+		}
+		storage.Start()
+	}
+	for _, exporterConfig := range Config.Exporters {
+		constructor, ok := exporterDrivers[exporterConfig.Driver]
+		if !ok {
+			log.Fatalf("Unknown exporter driver %s\n", exporterConfig.Driver)
+		}
+		exporterConfig.exporter = constructor(exporterConfig.Options)
+		exporterConfig.Start()
+	}
+	for name, monitorConfig := range Config.Monitors {
 		monitorConfig.monitor = monitorDrivers[monitorConfig.Driver](monitorConfig.Options)
-		monitorConfig.track = newMonitorTrack()
+		monitorConfig.track = newMonitorTrack(name, monitorConfig.Interval)
 		monitorConfig.track.Start(monitorConfig.monitor)
 	}
+	if len(Config.Computed) > 0 {
+		if _, ok := Config.Monitors["computed"]; ok {
+			log.Printf("Monitor computed already exists, not loading computed variables")
+		} else {
+			if Config.Monitors == nil {
+				Config.Monitors = make(map[string]*MonitorConfig)
+			}
+			monitorConfig := &MonitorConfig{
+				Driver:  "computed",
+				monitor: newComputedMonitor("computed", Config.Computed),
+				track:   newMonitorTrack("computed", 0),
+			}
+			Config.Monitors["computed"] = monitorConfig
+			monitorConfig.track.Start(monitorConfig.monitor)
+		}
+	}
+	if Config.Metrics != nil {
+		StartMetrics(Config.Metrics)
+	}
+	for name, alert := range Config.Alerts {
+		err := alert.Start(name)
+		if err != nil {
+			log.Printf("Error starting alert %s: %s", name, err)
+		}
+	}
 	quit := make(chan bool)
 	<-quit
 }
@@ -175,6 +221,19 @@ func Message(text string, source User, channel string, response MessageTarget) {
 				return
 			}
 			response.SendMessage("%s = %v", data[2], val)
+		} else if data[1] == "alerts" {
+			if len(data) < 3 {
+				for name, alert := range Config.Alerts {
+					response.SendMessage("%s: %s (%s)", name, alert.Status(), alert.Condition())
+				}
+				return
+			}
+			alert, ok := Config.Alerts[data[2]]
+			if !ok {
+				response.SendMessage("Alert `%s` not recognized", data[2])
+				return
+			}
+			response.SendMessage("%s: %s (%s), last value %v", data[2], alert.Status(), alert.Condition(), alert.Value())
 		} else if data[1] == "monitor" {
 			if len(data) < 3 {
 				if response.IsPublic() {
@@ -248,17 +307,20 @@ func Message(text string, source User, channel string, response MessageTarget) {
 						if response.IsPublic() {
 							response.SendMessage("Responding in PM")
 						}
-						source.SendMessage("History tracking for %d variables", len(monitor.track.Variables))
-						for variable, vt := range monitor.track.Variables {
-							source.SendMessage("%s = %d items", variable, vt.History)
+						tracked := monitor.track.Snapshot()
+						source.SendMessage("History tracking for %d variables", len(tracked))
+						for variable, vt := range tracked {
+							from, to := vt.Window()
+							source.SendMessage("%s = %d items, %s", variable, vt.History, formatWindow(len(vt.Data), from, to))
 						}
 						return
 					}
 					if len(data) < 6 {
-						if vt, ok := monitor.track.Variables[data[4]]; ok {
-							response.SendMessage("Not tracking history for variable %s of monitor %s", data[4], data[3])
+						if vt, ok := monitor.track.Variable(data[4]); ok {
+							from, to := vt.Window()
+							response.SendMessage("History tracking for variable %s of monitor %s set to %v items, %s", data[4], data[2], vt.History, formatWindow(len(vt.Data), from, to))
 						} else {
-							response.SendMessage("History tracking for variable %s of monitor %s set to %v items", data[4], data[3], vt.History)
+							response.SendMessage("Not tracking history for variable %s of monitor %s", data[4], data[2])
 						}
 						return
 					}
@@ -267,12 +329,11 @@ func Message(text string, source User, channel string, response MessageTarget) {
 						response.SendMessage("Error parsing %s: %s", data[5], err)
 						return
 					}
-					fmt.Println(monitor.track, data[4], h)
 					monitor.track.SetTrack(data[4], int(h))
-					response.SendMessage("History tracking for variable %s of monitor %s set to %v items", data[4], data[3], h)
+					response.SendMessage("History tracking for variable %s of monitor %s set to %v items", data[4], data[2], h)
 				case "interval":
 					if len(data) < 5 {
-						response.SendMessage("Interval for monitor %s set to %v", data[3], monitor.track.Interval)
+						response.SendMessage("Interval for monitor %s set to %v", data[2], monitor.track.GetInterval())
 						return
 					}
 					interval, err := strconv.ParseInt(data[4], 10, 32)
@@ -280,47 +341,55 @@ func Message(text string, source User, channel string, response MessageTarget) {
 						response.SendMessage("Error parsing %s: %s", data[4], err)
 						return
 					}
-					monitor.track.Interval = int(interval)
-					monitor.track.timer.Reset(time.Second * time.Duration(interval))
-					response.SendMessage("Interval for monitor %s set to %v", data[3], interval)
+					monitor.track.SetInterval(int(interval))
+					response.SendMessage("Interval for monitor %s set to %v", data[2], monitor.track.GetInterval())
 				case "spark":
 					if len(data) < 5 {
 						response.SendMessage("Please specify a variable to display")
 						return
 					}
-					vt, ok := monitor.track.Variables[data[4]]
+					vt, ok := monitor.track.Variable(data[4])
 					if !ok {
 						response.SendMessage("Not tracking that variable")
 						return
 					}
-					values := make([]float64, len(vt.Data))
+					since := time.Time{}
+					if len(data) > 5 {
+						span, err := ParseSpan(data[5])
+						if err != nil {
+							response.SendMessage("Error parsing %s: %s", data[5], err)
+							return
+						}
+						since = time.Now().Add(-span)
+					}
+					var points []MonitorRollup
+					if storage != nil && len(data) > 5 {
+						var err error
+						points, err = storage.Query(data[2], data[4], since)
+						if err != nil {
+							response.SendMessage("Error reading history for %s: %s", data[4], err)
+							return
+						}
+					} else {
+						points = vt.Rollups(since)
+					}
+					if len(points) == 0 {
+						response.SendMessage("No numeric history for %s yet", data[4])
+						return
+					}
+					values := make([]float64, len(points))
 					high := -math.MaxFloat64
 					low := math.MaxFloat64
-					for i, val := range vt.Data {
-						switch tt := val.(type) {
-						case float64:
-							values[i] = tt
-						case float32:
-							values[i] = float64(tt)
-						case uint32:
-							values[i] = float64(tt)
-						case uint64:
-							values[i] = float64(tt)
-						case int32:
-							values[i] = float64(tt)
-						case int64:
-							values[i] = float64(tt)
-						default:
-							response.SendMessage("Variable is of type %t, cannot spark", tt)
-						}
-						if values[i] > high {
-							high = values[i]
+					for i, point := range points {
+						values[i] = point.Avg
+						if point.Max > high {
+							high = point.Max
 						}
-						if values[i] < low {
-							low = values[i]
+						if point.Min < low {
+							low = point.Min
 						}
 					}
-					response.SendMessage("%s: %s High: %v Low: %v", data[4], spark.Line(values), high, low)
+					response.SendMessage("%s: %s High: %v Low: %v, %s", data[4], spark.Line(values), high, low, formatWindow(len(points), points[0].Time, points[len(points)-1].Time))
 				default:
 					response.SendMessage("Monitor command `%s` not recognized", data[3])
 				}
@@ -329,3 +398,21 @@ func Message(text string, source User, channel string, response MessageTarget) {
 		}
 	}
 }
+
+func formatWindow(samples int, from time.Time, to time.Time) string {
+	if samples == 0 {
+		return "no samples yet"
+	}
+	return fmt.Sprintf("%d samples covering %s (%s to %s)", samples, to.Sub(from), from.Format("Jan 2 15:04:05"), to.Format("Jan 2 15:04:05"))
+}
+
+func ParseSpan(span string) (time.Duration, error) {
+	if strings.HasSuffix(span, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(span, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(span)
+}
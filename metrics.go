@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type MetricsConfig struct {
+	Listen   string
+	Monitors []string
+}
+
+type metricsSample struct {
+	monitor string
+	value   float64
+}
+
+var metricsInvalidChars = regexp.MustCompile("[^a-zA-Z0-9_:]")
+
+func MetricName(variable string) string {
+	return "srvbot_" + metricsInvalidChars.ReplaceAllString(variable, "_")
+}
+
+func metricsLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return strings.Replace(value, `"`, `\"`, -1)
+}
+
+func StartMetrics(config *MetricsConfig) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, config)
+	})
+	go func() {
+		err := http.ListenAndServe(config.Listen, mux)
+		if err != nil {
+			log.Printf("Error serving metrics: %s", err)
+		}
+	}()
+}
+
+func collectMetrics(config *MetricsConfig) map[string][]metricsSample {
+	metrics := make(map[string][]metricsSample)
+	full := make(map[string]bool)
+	for _, name := range config.Monitors {
+		monitor, ok := Config.Monitors[name]
+		if !ok {
+			continue
+		}
+		full[name] = true
+		for variable, val := range monitor.monitor.GetValues(monitor.monitor.GetVariables()) {
+			if value, ok := ComputeFloat(val); ok {
+				metrics[MetricName(variable)] = append(metrics[MetricName(variable)], metricsSample{name, value})
+			}
+		}
+	}
+	for name, monitor := range Config.Monitors {
+		if full[name] {
+			continue
+		}
+		for variable, vt := range monitor.track.Snapshot() {
+			if len(vt.Data) == 0 {
+				continue
+			}
+			if point, ok := vt.Data[len(vt.Data)-1].Rollup(); ok {
+				metrics[MetricName(variable)] = append(metrics[MetricName(variable)], metricsSample{name, point.Avg})
+			}
+		}
+	}
+	return metrics
+}
+
+func writeMetrics(w http.ResponseWriter, config *MetricsConfig) {
+	metrics := collectMetrics(config)
+	names := []string{}
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		samples := metrics[name]
+		sort.Slice(samples, func(i, j int) bool { return samples[i].monitor < samples[j].monitor })
+		fmt.Fprintf(w, "# TYPE %s untyped\n", name)
+		for _, sample := range samples {
+			fmt.Fprintf(w, "%s{server=\"%s\",monitor=\"%s\"} %s\n", name, metricsLabel(Config.Name), metricsLabel(sample.monitor), strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,53 +24,350 @@ func AddMonitorDriver(monitor string, constructor func(*json.RawMessage) Monitor
 	monitorDrivers[monitor] = constructor
 }
 
+var invalidVariableName = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+func variableName(name string) string {
+	return invalidVariableName.ReplaceAllString(name, "_")
+}
+
+func dialAddress(address string, timeout int) (net.Conn, error) {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, address, time.Second*time.Duration(timeout))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(time.Second * time.Duration(timeout)))
+	return conn, nil
+}
+
+type CachedMonitor struct {
+	Cache   int
+	name    string
+	source  func() (map[string]interface{}, error)
+	values  map[string]interface{}
+	updated time.Time
+	lock    sync.Mutex
+}
+
+func (m *CachedMonitor) get() map[string]interface{} {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.values != nil && time.Since(m.updated) < time.Second*time.Duration(m.Cache) {
+		return m.values
+	}
+	values, err := m.source()
+	m.updated = time.Now()
+	if err != nil {
+		log.Printf("Error getting %s variables: %s", m.name, err)
+		values = map[string]interface{}{}
+	}
+	m.values = values
+	return m.values
+}
+
+func (m *CachedMonitor) GetVariables() []string {
+	variables := []string{}
+	for name := range m.get() {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (m *CachedMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	all := m.get()
+	for _, name := range names {
+		if value, ok := all[name]; ok {
+			values[name] = value
+		}
+	}
+	return
+}
+
+func sqlQuery(db *sql.DB, query string, handle func(columns []string, row []sql.NullString)) error {
+	data, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	columns, err := data.Columns()
+	if err != nil {
+		return err
+	}
+	for data.Next() {
+		row := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		err = data.Scan(dest...)
+		if err != nil {
+			return err
+		}
+		handle(columns, row)
+	}
+	return data.Err()
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func FlattenJSON(prefix string, data interface{}, values map[string]interface{}) {
+	switch tt := data.(type) {
+	case map[string]interface{}:
+		for key, value := range tt {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			FlattenJSON(key, value, values)
+		}
+	case []interface{}:
+		for i, value := range tt {
+			key := strconv.Itoa(i)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			FlattenJSON(key, value, values)
+		}
+	case bool:
+		if tt {
+			values[prefix] = 1.0
+		} else {
+			values[prefix] = 0.0
+		}
+	case nil:
+	default:
+		if prefix != "" {
+			values[prefix] = tt
+		}
+	}
+}
+
 type MonitorTrack struct {
-	Variables map[string]*MonitorTrackVariable
-	Interval  int
-	timer     *time.Timer
+	Variables       map[string]*MonitorTrackVariable
+	Interval        int
+	name            string
+	defaultInterval int
+	timer           *time.Timer
+	lock            sync.RWMutex
 }
 
-func newMonitorTrack() *MonitorTrack {
-	return &MonitorTrack{
-		Variables: make(map[string]*MonitorTrackVariable),
+func newMonitorTrack(name string, interval int) *MonitorTrack {
+	if interval <= 0 {
+		interval = 60
 	}
+	mt := &MonitorTrack{
+		Variables:       make(map[string]*MonitorTrackVariable),
+		name:            name,
+		defaultInterval: interval,
+	}
+	if storage != nil {
+		err := storage.Load(name, mt)
+		if err != nil {
+			log.Printf("Error loading history for monitor %s: %s", name, err)
+		}
+	}
+	return mt
+}
+
+func (mt *MonitorTrack) Snapshot() map[string]*MonitorTrackVariable {
+	mt.lock.RLock()
+	defer mt.lock.RUnlock()
+	snapshot := make(map[string]*MonitorTrackVariable, len(mt.Variables))
+	for variable, vt := range mt.Variables {
+		snapshot[variable] = &MonitorTrackVariable{
+			History: vt.History,
+			Data:    vt.Data[:len(vt.Data):len(vt.Data)],
+		}
+	}
+	return snapshot
+}
+
+func (mt *MonitorTrack) Variable(variable string) (*MonitorTrackVariable, bool) {
+	mt.lock.RLock()
+	defer mt.lock.RUnlock()
+	vt, ok := mt.Variables[variable]
+	if !ok {
+		return nil, false
+	}
+	return &MonitorTrackVariable{
+		History: vt.History,
+		Data:    vt.Data[:len(vt.Data):len(vt.Data)],
+	}, true
+}
+
+func (mt *MonitorTrack) interval() int {
+	if mt.Interval > 0 {
+		return mt.Interval
+	}
+	return mt.defaultInterval
+}
+
+func (mt *MonitorTrack) GetInterval() int {
+	mt.lock.RLock()
+	defer mt.lock.RUnlock()
+	return mt.interval()
 }
 
 type MonitorTrackVariable struct {
 	History int
-	Data    []interface{}
+	Data    []MonitorSample
+}
+
+type MonitorSample struct {
+	Time  time.Time
+	Value interface{}
+	Valid bool
+}
+
+type MonitorRollup struct {
+	Time  time.Time
+	Min   float64
+	Avg   float64
+	Max   float64
+	Count int
+}
+
+func (sample MonitorSample) Rollup() (MonitorRollup, bool) {
+	if !sample.Valid {
+		return MonitorRollup{}, false
+	}
+	value, ok := ComputeFloat(sample.Value)
+	if !ok {
+		return MonitorRollup{}, false
+	}
+	return MonitorRollup{
+		Time:  sample.Time,
+		Min:   value,
+		Avg:   value,
+		Max:   value,
+		Count: 1,
+	}, true
+}
+
+func (vt *MonitorTrackVariable) Window() (from time.Time, to time.Time) {
+	if len(vt.Data) == 0 {
+		return
+	}
+	return vt.Data[0].Time, vt.Data[len(vt.Data)-1].Time
+}
+
+func (vt *MonitorTrackVariable) Rollups(since time.Time) []MonitorRollup {
+	points := []MonitorRollup{}
+	for _, sample := range vt.Data {
+		if sample.Time.Before(since) {
+			continue
+		}
+		if point, ok := sample.Rollup(); ok {
+			points = append(points, point)
+		}
+	}
+	return points
 }
 
 func (mt *MonitorTrack) SetTrack(variable string, history int) {
+	mt.lock.Lock()
 	track, ok := mt.Variables[variable]
-	if !ok && history > 0 {
-		track = &MonitorTrackVariable{}
-		mt.Variables[variable] = track
+	removed := false
+	if history <= 0 {
+		if ok {
+			delete(mt.Variables, variable)
+			removed = true
+		}
+	} else {
+		if !ok {
+			track = &MonitorTrackVariable{}
+			mt.Variables[variable] = track
+		}
+		track.History = history
+	}
+	mt.lock.Unlock()
+	if removed && storage != nil {
+		if err := storage.Remove(mt.name, variable); err != nil {
+			log.Printf("Error removing history for %s.%s: %s", mt.name, variable, err)
+		}
+	}
+	mt.save()
+}
+
+func (mt *MonitorTrack) SetInterval(interval int) {
+	mt.lock.Lock()
+	mt.Interval = interval
+	if mt.timer != nil {
+		mt.timer.Reset(time.Second * time.Duration(mt.interval()))
 	}
-	if history == 0 && ok {
-		delete(mt.Variables, variable)
+	mt.lock.Unlock()
+	mt.save()
+}
+
+func (mt *MonitorTrack) save() {
+	if storage == nil {
 		return
 	}
-	track.History = history
+	mt.lock.RLock()
+	defer mt.lock.RUnlock()
+	err := storage.SaveSettings(mt.name, mt)
+	if err != nil {
+		log.Printf("Error saving track settings for monitor %s: %s", mt.name, err)
+	}
+}
+
+type monitorTrackSample struct {
+	variable string
+	sample   MonitorSample
 }
 
 func (mt *MonitorTrack) Start(monitor Monitor) {
+	mt.lock.Lock()
+	mt.timer = time.NewTimer(time.Duration(1) * time.Second)
+	mt.lock.Unlock()
 	go func() {
-		mt.timer = time.NewTimer(time.Duration(1) * time.Second)
 		for _ = range mt.timer.C {
-			if mt.Interval > 0 {
-				mt.timer.Reset(time.Second * time.Duration(mt.Interval))
-			}
+			mt.lock.RLock()
+			mt.timer.Reset(time.Second * time.Duration(mt.interval()))
 			variables := []string{}
 			for variable := range mt.Variables {
 				variables = append(variables, variable)
 			}
+			mt.lock.RUnlock()
 			values := monitor.GetValues(variables)
+			now := time.Now()
+			samples := []monitorTrackSample{}
+			mt.lock.Lock()
 			for variable, vt := range mt.Variables {
-				vt.Data = append(vt.Data, values[variable])
+				value, ok := values[variable]
+				sample := MonitorSample{
+					Time:  now,
+					Value: value,
+					Valid: ok,
+				}
+				vt.Data = append(vt.Data, sample)
 				if len(vt.Data) > vt.History {
 					vt.Data = vt.Data[len(vt.Data)-vt.History:]
 				}
+				samples = append(samples, monitorTrackSample{variable, sample})
+			}
+			mt.lock.Unlock()
+			for _, tracked := range samples {
+				if storage != nil {
+					err := storage.Append(mt.name, tracked.variable, tracked.sample)
+					if err != nil {
+						log.Printf("Error storing history for %s.%s: %s", mt.name, tracked.variable, err)
+					}
+				}
+				for _, exporterConfig := range Config.Exporters {
+					exporterConfig.Add(mt.name, tracked.variable, tracked.sample)
+				}
 			}
 		}
 	}()
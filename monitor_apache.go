@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	AddMonitorDriver("apache", func(options *json.RawMessage) Monitor {
+		m := &StatusPageMonitor{
+			name:     "apache",
+			parse:    ParseApacheStatus,
+			counters: []string{"Total_Accesses", "Total_kBytes"},
+		}
+		json.Unmarshal(*options, &m)
+		if m.URL == "" {
+			m.URL = "http://localhost/server-status"
+		}
+		if !strings.Contains(m.URL, "?") {
+			m.URL += "?auto"
+		}
+		m.Start()
+		return m
+	})
+}
+
+var apacheScoreboard = map[rune]string{
+	'_': "waiting",
+	'S': "starting",
+	'R': "reading",
+	'W': "sending",
+	'K': "keepalive",
+	'D': "dns",
+	'C': "closing",
+	'L': "logging",
+	'G': "finishing",
+	'I': "idle_cleanup",
+	'.': "open",
+}
+
+func ParseApacheStatus(status string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, line := range strings.Split(status, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if name == "Scoreboard" {
+			for _, state := range apacheScoreboard {
+				values["scoreboard."+state] = 0
+			}
+			for _, slot := range value {
+				if state, ok := apacheScoreboard[slot]; ok {
+					values["scoreboard."+state]++
+				}
+			}
+			continue
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			values[variableName(name)] = v
+		}
+	}
+	return values
+}
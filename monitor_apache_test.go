@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseApacheStatus(t *testing.T) {
+	status := "localhost\n" +
+		"ServerVersion: Apache/2.4.57 (Unix)\n" +
+		"Total Accesses: 130\n" +
+		"Total kBytes: 456\n" +
+		"CPULoad: .01\n" +
+		"Uptime: 100\n" +
+		"ReqPerSec: 1.3\n" +
+		"BusyWorkers: 2\n" +
+		"IdleWorkers: 8\n" +
+		"Scoreboard: _W___K..R.\n"
+	want := map[string]float64{
+		"Total_Accesses":          130,
+		"Total_kBytes":            456,
+		"CPULoad":                 0.01,
+		"Uptime":                  100,
+		"ReqPerSec":               1.3,
+		"BusyWorkers":             2,
+		"IdleWorkers":             8,
+		"scoreboard.waiting":      4,
+		"scoreboard.starting":     0,
+		"scoreboard.reading":      1,
+		"scoreboard.sending":      1,
+		"scoreboard.keepalive":    1,
+		"scoreboard.dns":          0,
+		"scoreboard.closing":      0,
+		"scoreboard.logging":      0,
+		"scoreboard.finishing":    0,
+		"scoreboard.idle_cleanup": 0,
+		"scoreboard.open":         3,
+	}
+	if got := ParseApacheStatus(status); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseApacheStatus = %v, want %v", got, want)
+	}
+}
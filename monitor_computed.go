@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+type ComputedMonitor struct {
+	Variables map[string]ComputedVariable
+}
+
+func newComputedMonitor(name string, exprs map[string]string) *ComputedMonitor {
+	m := &ComputedMonitor{
+		Variables: make(map[string]ComputedVariable),
+	}
+	for variable, expr := range exprs {
+		cv, err := NewComputedVariable(variable, expr)
+		if err != nil {
+			log.Printf("Error parsing computed variable %s: %s", variable, err)
+			continue
+		}
+		m.Variables[variable] = cv
+	}
+	for variable := range m.Variables {
+		err := m.check(name, variable, map[string]bool{})
+		if err != nil {
+			log.Printf("Error in computed variable %s: %s", variable, err)
+			delete(m.Variables, variable)
+		}
+	}
+	return m
+}
+
+func (m *ComputedMonitor) check(name string, variable string, seen map[string]bool) error {
+	cv, ok := m.Variables[variable]
+	if !ok {
+		return fmt.Errorf("Can't find variable %s.%s", name, variable)
+	}
+	if seen[variable] {
+		return fmt.Errorf("Computed variable %s has a circular reference", variable)
+	}
+	seen[variable] = true
+	defer delete(seen, variable)
+	for _, v := range cv.compute.GetVars() {
+		if v.Monitor == name {
+			if err := m.check(name, v.Variable, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := Config.Monitors[v.Monitor]; !ok {
+			return fmt.Errorf("Can't find monitor %s", v.Monitor)
+		}
+	}
+	return nil
+}
+
+func (m *ComputedMonitor) GetVariables() []string {
+	variables := []string{}
+	for variable := range m.Variables {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (m *ComputedMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	cvs := []ComputedVariable{}
+	for _, name := range names {
+		if cv, ok := m.Variables[name]; ok {
+			cvs = append(cvs, cv)
+		}
+	}
+	if len(cvs) == 0 {
+		return
+	}
+	for name, val := range RunComputeds(cvs) {
+		values[name] = val
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("cpu", func(options *json.RawMessage) Monitor {
+		m := &CPUMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.File == "" {
+			m.File = "/proc/stat"
+		}
+		m.Start()
+		return m
+	})
+}
+
+var cpuFields = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal"}
+
+type CPUMonitor struct {
+	File string
+	prev *cpuStat
+	lock sync.Mutex
+}
+
+type cpuStat struct {
+	time  time.Time
+	cpus  map[string][]uint64
+	ctxt  uint64
+	intr  uint64
+	procs map[string]uint64
+}
+
+func (m *CPUMonitor) Start() {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting cpu variables: %s", err)
+		return
+	}
+	m.prev = stat
+}
+
+func (m *CPUMonitor) read() (*cpuStat, error) {
+	data, err := ioutil.ReadFile(m.File)
+	if err != nil {
+		return nil, err
+	}
+	stat := &cpuStat{
+		time:  time.Now(),
+		cpus:  make(map[string][]uint64),
+		procs: make(map[string]uint64),
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fields[0], "cpu"):
+			values := make([]uint64, len(cpuFields))
+			for i := range cpuFields {
+				if i+1 < len(fields) {
+					values[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+				}
+			}
+			stat.cpus[fields[0]] = values
+		case fields[0] == "ctxt":
+			stat.ctxt, _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "intr":
+			stat.intr, _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "processes" || fields[0] == "procs_running" || fields[0] == "procs_blocked":
+			stat.procs[fields[0]], _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "btime":
+			btime, _ := strconv.ParseInt(fields[1], 10, 64)
+			stat.procs["btime"] = uint64(btime)
+		}
+	}
+	return stat, nil
+}
+
+func (m *CPUMonitor) GetVariables() []string {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting cpu variables: %s", err)
+		return []string{}
+	}
+	cpus := []string{}
+	for cpu := range stat.cpus {
+		cpus = append(cpus, cpu)
+	}
+	sort.Strings(cpus)
+	variables := []string{}
+	for _, cpu := range cpus {
+		for _, field := range cpuFields {
+			variables = append(variables, cpu+"."+field)
+		}
+		variables = append(variables, cpu+".busy")
+	}
+	variables = append(variables, "ctxt", "ctxt_rate", "intr", "intr_rate", "processes", "procs_running", "procs_blocked")
+	return variables
+}
+
+func (m *CPUMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting cpu variables: %s", err)
+		return
+	}
+	m.lock.Lock()
+	prev := m.prev
+	if prev == nil || stat.time.Sub(prev.time) >= time.Second {
+		m.prev = stat
+	}
+	m.lock.Unlock()
+	if prev == nil {
+		prev = &cpuStat{
+			time: time.Unix(int64(stat.procs["btime"]), 0),
+			cpus: make(map[string][]uint64),
+		}
+	}
+	elapsed := stat.time.Sub(prev.time).Seconds()
+
+	all := make(map[string]interface{})
+	for cpu, cur := range stat.cpus {
+		last, ok := prev.cpus[cpu]
+		if !ok {
+			last = make([]uint64, len(cpuFields))
+		}
+		deltas := make([]float64, len(cpuFields))
+		total := 0.0
+		for i := range cpuFields {
+			if cur[i] > last[i] {
+				deltas[i] = float64(cur[i] - last[i])
+			}
+			total += deltas[i]
+		}
+		if total == 0 {
+			continue
+		}
+		for i, field := range cpuFields {
+			all[cpu+"."+field] = deltas[i] * 100 / total
+		}
+		all[cpu+".busy"] = 100 - (deltas[3]+deltas[4])*100/total
+	}
+	all["ctxt"] = stat.ctxt
+	all["intr"] = stat.intr
+	if elapsed > 0 {
+		all["ctxt_rate"] = float64(stat.ctxt-prev.ctxt) / elapsed
+		all["intr_rate"] = float64(stat.intr-prev.intr) / elapsed
+	}
+	for _, name := range []string{"processes", "procs_running", "procs_blocked"} {
+		all[name] = stat.procs[name]
+	}
+
+	for _, name := range names {
+		if value, ok := all[name]; ok {
+			values[name] = value
+		}
+	}
+	return
+}
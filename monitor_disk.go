@@ -0,0 +1,169 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func init() {
+	AddMonitorDriver("disk", func(options *json.RawMessage) Monitor {
+		m := &DiskMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.File == "" {
+			m.File = "/proc/mounts"
+		}
+		if m.ExcludeTypes == nil {
+			m.ExcludeTypes = []string{"proc", "sysfs", "devtmpfs", "devpts", "tmpfs", "cgroup", "cgroup2", "securityfs",
+				"debugfs", "tracefs", "pstore", "bpf", "mqueue", "hugetlbfs", "configfs", "fusectl", "autofs",
+				"binfmt_misc", "rpc_pipefs", "nsfs", "squashfs", "overlay"}
+		}
+		m.Start()
+		return m
+	})
+}
+
+var diskFields = []string{"total_bytes", "free_bytes", "used_bytes", "used_pct", "inodes_total", "inodes_free", "inodes_used", "inodes_used_pct"}
+
+type DiskMonitor struct {
+	File          string
+	IncludeTypes  []string
+	ExcludeTypes  []string
+	IncludeMounts []string
+	ExcludeMounts []string
+}
+
+type diskMount struct {
+	name   string
+	path   string
+	fstype string
+}
+
+var diskEscape = regexp.MustCompile(`\\[0-7]{3}`)
+
+func (m *DiskMonitor) Start() {
+
+}
+
+func diskMountName(path string) string {
+	name := variableName(strings.Trim(path, "/"))
+	switch name {
+	case "":
+		return "root"
+	case "root":
+		return "root_dir"
+	}
+	return name
+}
+
+func (m *DiskMonitor) mounts() []diskMount {
+	data, err := ioutil.ReadFile(m.File)
+	if err != nil {
+		log.Printf("Error getting disk variables: %s", err)
+		return nil
+	}
+	mounts := []diskMount{}
+	seen := make(map[string]int)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		path := diskEscape.ReplaceAllStringFunc(fields[1], func(s string) string {
+			c, _ := strconv.ParseUint(s[1:], 8, 8)
+			return string([]byte{byte(c)})
+		})
+		fstype := fields[2]
+		if len(m.IncludeTypes) > 0 && !containsString(m.IncludeTypes, fstype) {
+			continue
+		}
+		if containsString(m.ExcludeTypes, fstype) {
+			continue
+		}
+		if len(m.IncludeMounts) > 0 && !containsString(m.IncludeMounts, path) {
+			continue
+		}
+		if containsString(m.ExcludeMounts, path) {
+			continue
+		}
+		mount := diskMount{
+			name:   diskMountName(path),
+			path:   path,
+			fstype: fstype,
+		}
+		if i, ok := seen[mount.name]; ok {
+			if mounts[i].path == path {
+				mounts[i] = mount
+			} else {
+				log.Printf("Disk mounts %s and %s are both named %s, ignoring %s", mounts[i].path, path, mount.name, path)
+			}
+			continue
+		}
+		seen[mount.name] = len(mounts)
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+func (m *DiskMonitor) GetVariables() []string {
+	variables := []string{}
+	for _, mount := range m.mounts() {
+		for _, field := range diskFields {
+			variables = append(variables, mount.name+"."+field)
+		}
+	}
+	return variables
+}
+
+func (m *DiskMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		if pos := strings.LastIndex(name, "."); pos >= 0 {
+			wanted[name[:pos]] = true
+		}
+	}
+	for _, mount := range m.mounts() {
+		if !wanted[mount.name] {
+			continue
+		}
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(mount.path, &stat)
+		if err != nil {
+			log.Printf("Error getting disk variables for %s: %s", mount.path, err)
+			continue
+		}
+		bsize := uint64(stat.Bsize)
+		used := (stat.Blocks - stat.Bfree) * bsize
+		all := map[string]interface{}{
+			"total_bytes":  stat.Blocks * bsize,
+			"free_bytes":   stat.Bavail * bsize,
+			"used_bytes":   used,
+			"inodes_total": stat.Files,
+			"inodes_free":  stat.Ffree,
+			"inodes_used":  stat.Files - stat.Ffree,
+		}
+		if used+stat.Bavail*bsize > 0 {
+			all["used_pct"] = float64(used) * 100 / float64(used+stat.Bavail*bsize)
+		}
+		if stat.Files > 0 {
+			all["inodes_used_pct"] = float64(stat.Files-stat.Ffree) * 100 / float64(stat.Files)
+		}
+		for field, value := range all {
+			values[mount.name+"."+field] = value
+		}
+	}
+	for name := range values {
+		if !containsString(names, name) {
+			delete(values, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("diskio", func(options *json.RawMessage) Monitor {
+		m := &DiskIOMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.File == "" {
+			m.File = "/proc/diskstats"
+		}
+		m.Start()
+		return m
+	})
+}
+
+var diskIOFields = []string{"reads", "writes", "read_bytes", "write_bytes", "reads_per_sec", "writes_per_sec", "read_bytes_per_sec", "write_bytes_per_sec", "await_ms", "in_progress", "util_pct"}
+
+type DiskIOMonitor struct {
+	File    string
+	Devices []string
+	prev    *diskIOStat
+	lock    sync.Mutex
+}
+
+type diskIOStat struct {
+	time    time.Time
+	devices map[string][]uint64
+}
+
+const (
+	diskIOReads = iota
+	diskIOReadsMerged
+	diskIOSectorsRead
+	diskIOReadTime
+	diskIOWrites
+	diskIOWritesMerged
+	diskIOSectorsWritten
+	diskIOWriteTime
+	diskIOInProgress
+	diskIOTime
+	diskIOWeightedTime
+	diskIOFieldCount
+)
+
+func (m *DiskIOMonitor) Start() {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting diskio variables: %s", err)
+		return
+	}
+	m.prev = stat
+}
+
+func (m *DiskIOMonitor) read() (*diskIOStat, error) {
+	data, err := ioutil.ReadFile(m.File)
+	if err != nil {
+		return nil, err
+	}
+	stat := &diskIOStat{
+		time:    time.Now(),
+		devices: make(map[string][]uint64),
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3+diskIOFieldCount {
+			continue
+		}
+		device := fields[2]
+		if len(m.Devices) > 0 {
+			if !containsString(m.Devices, device) {
+				continue
+			}
+		} else if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") {
+			continue
+		}
+		values := make([]uint64, diskIOFieldCount)
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[3+i], 10, 64)
+		}
+		stat.devices[variableName(device)] = values
+	}
+	return stat, nil
+}
+
+func (m *DiskIOMonitor) GetVariables() []string {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting diskio variables: %s", err)
+		return []string{}
+	}
+	devices := []string{}
+	for device := range stat.devices {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	variables := []string{}
+	for _, device := range devices {
+		for _, field := range diskIOFields {
+			variables = append(variables, device+"."+field)
+		}
+	}
+	return variables
+}
+
+func diskIODelta(cur []uint64, prev []uint64, field int) float64 {
+	if cur[field] < prev[field] {
+		return float64(cur[field])
+	}
+	return float64(cur[field] - prev[field])
+}
+
+func (m *DiskIOMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting diskio variables: %s", err)
+		return
+	}
+	m.lock.Lock()
+	prev := m.prev
+	if prev == nil || stat.time.Sub(prev.time) >= time.Second {
+		m.prev = stat
+	}
+	m.lock.Unlock()
+
+	all := make(map[string]interface{})
+	for device, cur := range stat.devices {
+		all[device+".reads"] = cur[diskIOReads]
+		all[device+".writes"] = cur[diskIOWrites]
+		all[device+".read_bytes"] = cur[diskIOSectorsRead] * 512
+		all[device+".write_bytes"] = cur[diskIOSectorsWritten] * 512
+		all[device+".in_progress"] = cur[diskIOInProgress]
+		if prev == nil {
+			continue
+		}
+		last, ok := prev.devices[device]
+		elapsed := stat.time.Sub(prev.time).Seconds()
+		if !ok || elapsed <= 0 {
+			continue
+		}
+		reads := diskIODelta(cur, last, diskIOReads)
+		writes := diskIODelta(cur, last, diskIOWrites)
+		all[device+".reads_per_sec"] = reads / elapsed
+		all[device+".writes_per_sec"] = writes / elapsed
+		all[device+".read_bytes_per_sec"] = diskIODelta(cur, last, diskIOSectorsRead) * 512 / elapsed
+		all[device+".write_bytes_per_sec"] = diskIODelta(cur, last, diskIOSectorsWritten) * 512 / elapsed
+		all[device+".util_pct"] = math.Min(diskIODelta(cur, last, diskIOTime)/(elapsed*1000)*100, 100)
+		if reads+writes > 0 {
+			all[device+".await_ms"] = (diskIODelta(cur, last, diskIOReadTime) + diskIODelta(cur, last, diskIOWriteTime)) / (reads + writes)
+		} else {
+			all[device+".await_ms"] = 0.0
+		}
+	}
+
+	for _, name := range names {
+		if value, ok := all[name]; ok {
+			values[name] = value
+		}
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("exec", func(options *json.RawMessage) Monitor {
+		m := &ExecMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		if m.Format == "" {
+			m.Format = "auto"
+		}
+		m.Start()
+		return m
+	})
+}
+
+type ExecMonitor struct {
+	CachedMonitor
+	Command string
+	Timeout int
+	Format  string
+}
+
+func (m *ExecMonitor) Start() {
+	m.name = "exec"
+	m.source = m.fetch
+}
+
+func (m *ExecMonitor) fetch() (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(m.Timeout))
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", "-c", m.Command)
+	cmd.WaitDelay = time.Second
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return ParseExecOutput(string(output), m.Format), nil
+}
+
+func execValue(value string) interface{} {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+func ParseExecOutput(output string, format string) map[string]interface{} {
+	values := make(map[string]interface{})
+	trimmed := strings.TrimSpace(output)
+	if format == "json" || (format == "auto" && strings.HasPrefix(trimmed, "{")) {
+		var data interface{}
+		err := json.Unmarshal([]byte(trimmed), &data)
+		if err != nil {
+			log.Printf("Error parsing exec monitor output: %s", err)
+			return values
+		}
+		FlattenJSON("", data, values)
+		return values
+	}
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if format == "pairs" || (format == "auto" && strings.Contains(fields[0], "=")) {
+			for _, pair := range fields {
+				parts := strings.SplitN(pair, "=", 2)
+				if len(parts) == 2 {
+					values[parts[0]] = execValue(parts[1])
+				}
+			}
+			continue
+		}
+		if len(fields) >= 2 {
+			values[fields[0]] = execValue(strings.Join(fields[1:], " "))
+		}
+	}
+	return values
+}
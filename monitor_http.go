@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("http", func(options *json.RawMessage) Monitor {
+		m := &HTTPMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Interval <= 0 {
+			m.Interval = 60
+		}
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		m.Start()
+		return m
+	})
+}
+
+type HTTPMonitor struct {
+	Interval int
+	Timeout  int
+	Checks   map[string]*HTTPCheck
+	values   map[string]interface{}
+	lock     sync.Mutex
+}
+
+type HTTPCheck struct {
+	URL      string
+	Method   string
+	Regex    string
+	Insecure bool
+	regex    *regexp.Regexp
+	client   *http.Client
+}
+
+func (m *HTTPMonitor) Start() {
+	m.values = make(map[string]interface{})
+	for name, check := range m.Checks {
+		if check.Method == "" {
+			check.Method = "GET"
+		}
+		if check.Regex != "" {
+			var err error
+			check.regex, err = regexp.Compile(check.Regex)
+			if err != nil {
+				log.Printf("Error compiling regex for http check %s: %s", name, err)
+			}
+		}
+		check.client = &http.Client{
+			Timeout: time.Second * time.Duration(m.Timeout),
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: check.Insecure},
+			},
+		}
+	}
+	go func() {
+		for {
+			m.CheckAll()
+			time.Sleep(time.Second * time.Duration(m.Interval))
+		}
+	}()
+}
+
+func (m *HTTPMonitor) CheckAll() {
+	var wg sync.WaitGroup
+	for name, check := range m.Checks {
+		wg.Add(1)
+		go func(name string, check *HTTPCheck) {
+			defer wg.Done()
+			values := check.Run()
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			for _, field := range []string{"up", "status", "response_ms", "body_bytes", "regex_match", "cert_days"} {
+				delete(m.values, name+"."+field)
+			}
+			for field, value := range values {
+				m.values[name+"."+field] = value
+			}
+		}(name, check)
+	}
+	wg.Wait()
+}
+
+func (check *HTTPCheck) Run() map[string]interface{} {
+	values := map[string]interface{}{
+		"up": 0.0,
+	}
+	req, err := http.NewRequest(check.Method, check.URL, nil)
+	if err != nil {
+		log.Printf("Error creating request for %s: %s", check.URL, err)
+		return values
+	}
+	start := time.Now()
+	resp, err := check.client.Do(req)
+	if err != nil {
+		log.Printf("Error requesting %s: %s", check.URL, err)
+		return values
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Printf("Error reading %s: %s", check.URL, err)
+		return values
+	}
+	values["status"] = float64(resp.StatusCode)
+	values["response_ms"] = elapsed.Seconds() * 1000
+	values["body_bytes"] = float64(len(body))
+	up := resp.StatusCode < 400
+	if check.regex != nil {
+		if check.regex.Match(body) {
+			values["regex_match"] = 1.0
+		} else {
+			values["regex_match"] = 0.0
+			up = false
+		}
+	}
+	if up {
+		values["up"] = 1.0
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		values["cert_days"] = resp.TLS.PeerCertificates[0].NotAfter.Sub(time.Now()).Hours() / 24
+	}
+	return values
+}
+
+func (m *HTTPMonitor) GetVariables() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	variables := []string{}
+	for name := range m.values {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (m *HTTPMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, name := range names {
+		if value, ok := m.values[name]; ok {
+			values[name] = value
+		}
+	}
+	return
+}
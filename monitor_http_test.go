@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestHTTPCheckRun(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("status: ok"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		regex      string
+		up         float64
+		status     float64
+		regexMatch interface{}
+	}{
+		{"/", "", 1, 200, nil},
+		{"/", "status: ok", 1, 200, 1.0},
+		{"/", "status: down", 0, 200, 0.0},
+		{"/missing", "", 0, 404, nil},
+	}
+	for _, test := range tests {
+		check := &HTTPCheck{
+			URL:    srv.URL + test.path,
+			Method: "GET",
+			client: srv.Client(),
+		}
+		if test.regex != "" {
+			check.regex = regexp.MustCompile(test.regex)
+		}
+		values := check.Run()
+		if values["up"] != test.up {
+			t.Errorf("%s %q: up = %v, want %v", test.path, test.regex, values["up"], test.up)
+		}
+		if values["status"] != test.status {
+			t.Errorf("%s %q: status = %v, want %v", test.path, test.regex, values["status"], test.status)
+		}
+		if values["regex_match"] != test.regexMatch {
+			t.Errorf("%s %q: regex_match = %v, want %v", test.path, test.regex, values["regex_match"], test.regexMatch)
+		}
+		if days, ok := values["cert_days"].(float64); !ok || days <= 0 {
+			t.Errorf("%s %q: cert_days = %v, want a positive number", test.path, test.regex, values["cert_days"])
+		}
+	}
+}
+
+func TestHTTPCheckRunUnreachable(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	check := &HTTPCheck{URL: srv.URL, Method: "GET", client: srv.Client()}
+	srv.Close()
+	values := check.Run()
+	if values["up"] != 0.0 {
+		t.Errorf("up = %v, want 0", values["up"])
+	}
+	if _, ok := values["status"]; ok {
+		t.Errorf("status = %v, want no status", values["status"])
+	}
+}
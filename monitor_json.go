@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("json", func(options *json.RawMessage) Monitor {
+		m := &JSONMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		m.Start()
+		return m
+	})
+}
+
+type JSONMonitor struct {
+	CachedMonitor
+	URL     string
+	Socket  string
+	Path    string
+	Timeout int
+	Filters []string
+	client  *http.Client
+}
+
+func (m *JSONMonitor) Start() {
+	m.name = "json"
+	m.source = m.fetch
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+	if m.Socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", m.Socket)
+		}
+		if m.URL == "" {
+			m.URL = "http://localhost" + m.Path
+		}
+	}
+	m.client = &http.Client{
+		Timeout:   time.Second * time.Duration(m.Timeout),
+		Transport: transport,
+	}
+}
+
+func (m *JSONMonitor) filtered(name string) bool {
+	if len(m.Filters) == 0 {
+		return true
+	}
+	for _, filter := range m.Filters {
+		if name == filter || strings.HasPrefix(name, filter+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *JSONMonitor) fetch() (map[string]interface{}, error) {
+	resp, err := m.client.Get(m.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s returned %s", m.URL, resp.Status)
+	}
+	var data interface{}
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]interface{})
+	FlattenJSON("", data, all)
+	values := make(map[string]interface{})
+	for name, value := range all {
+		if m.filtered(name) {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	AddMonitorDriver("memcached", func(options *json.RawMessage) Monitor {
+		m := &MemcachedMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Address == "" {
+			m.Address = "localhost:11211"
+		}
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		m.Start()
+		return m
+	})
+}
+
+type MemcachedMonitor struct {
+	CachedMonitor
+	Address string
+	Timeout int
+}
+
+func (m *MemcachedMonitor) Start() {
+	m.name = "memcached"
+	m.source = m.fetch
+}
+
+func ParseMemcachedStats(r *bufio.Reader) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "END" {
+			return values, nil
+		}
+		if line == "ERROR" || strings.HasPrefix(line, "SERVER_ERROR") || strings.HasPrefix(line, "CLIENT_ERROR") {
+			return nil, fmt.Errorf("%s", line)
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			values[variableName(fields[1])] = v
+		}
+	}
+}
+
+func (m *MemcachedMonitor) fetch() (map[string]interface{}, error) {
+	conn, err := dialAddress(m.Address, m.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_, err = io.WriteString(conn, "stats\r\n")
+	if err != nil {
+		return nil, err
+	}
+	return ParseMemcachedStats(bufio.NewReader(conn))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMemcachedStats(t *testing.T) {
+	stats := "STAT pid 1\r\n" +
+		"STAT version 1.6.21\r\n" +
+		"STAT rusage_user 0.5\r\n" +
+		"STAT curr_connections 5\r\n" +
+		"STAT get_hits 7\r\n" +
+		"STAT get_misses 3\r\n" +
+		"STAT evictions 0\r\n" +
+		"END\r\n"
+	got, err := ParseMemcachedStats(bufio.NewReader(strings.NewReader(stats)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"pid":              1.0,
+		"rusage_user":      0.5,
+		"curr_connections": 5.0,
+		"get_hits":         7.0,
+		"get_misses":       3.0,
+		"evictions":        0.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMemcachedStats = %v, want %v", got, want)
+	}
+
+	for _, stats := range []string{"ERROR\r\n", "SERVER_ERROR out of memory\r\n", "STAT pid 1\r\n"} {
+		if _, err := ParseMemcachedStats(bufio.NewReader(strings.NewReader(stats))); err == nil {
+			t.Errorf("ParseMemcachedStats(%q) succeeded, want error", stats)
+		}
+	}
+}
+
+func TestMemcachedMonitorFetch(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	requests := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request, err := bufio.NewReader(conn).ReadString('\n')
+		requests <- request
+		if err != nil {
+			return
+		}
+		io.WriteString(conn, "STAT pid 1\r\nSTAT curr_connections 5\r\nSTAT version 1.6.21\r\nEND\r\n")
+	}()
+	m := &MemcachedMonitor{Address: listener.Addr().String(), Timeout: 5}
+	m.Start()
+	got, err := m.fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"pid":              1.0,
+		"curr_connections": 5.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetch = %v, want %v", got, want)
+	}
+	if request := <-requests; request != "stats\r\n" {
+		t.Errorf("server received %q, want %q", request, "stats\r\n")
+	}
+}
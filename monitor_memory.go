@@ -38,8 +38,11 @@ func (m *MemoryMonitor) GetVariables() []string {
 	lines := strings.Split(memstring, "\n")
 	variables := []string{}
 	for _, line := range lines {
-		name := strings.Split(line, ":")[0]
-		variables = append(variables, name)
+		parts := strings.Split(line, ":")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		variables = append(variables, parts[0])
 	}
 	return variables
 }
@@ -55,6 +58,9 @@ func (m *MemoryMonitor) GetValues(names []string) (values map[string]interface{}
 	lines := strings.Split(memstring, "\n")
 	for _, line := range lines {
 		parts := strings.Split(line, ":")
+		if len(parts) < 2 {
+			continue
+		}
 		found := false
 		for _, name := range names {
 			if name == parts[0] {
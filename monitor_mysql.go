@@ -6,6 +6,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -20,8 +21,10 @@ func init() {
 }
 
 type MysqlMonitor struct {
-	Connection string
-	db         *sql.DB
+	Connection   string
+	db           *sql.DB
+	replicaQuery string
+	replicaLock  sync.Mutex
 }
 
 func (m *MysqlMonitor) Start() {
@@ -36,36 +39,187 @@ func (m *MysqlMonitor) Start() {
 	}
 }
 
+func (m *MysqlMonitor) sections() map[string]func() map[string]interface{} {
+	return map[string]func() map[string]interface{}{
+		"variables.":   m.globalVariables,
+		"slave.":       m.slaveStatus,
+		"innodb.":      m.innodb,
+		"processlist.": m.processlist,
+	}
+}
+
+func (m *MysqlMonitor) query(query string, handle func(columns []string, row []sql.NullString)) {
+	err := sqlQuery(m.db, query, handle)
+	if err != nil {
+		log.Printf("Error getting monitor values: %s", err)
+	}
+}
+
+func mysqlValue(value sql.NullString) (float64, bool) {
+	if !value.Valid {
+		return 0, false
+	}
+	switch strings.ToUpper(value.String) {
+	case "ON", "YES":
+		return 1, true
+	case "OFF", "NO":
+		return 0, true
+	}
+	v, err := strconv.ParseFloat(value.String, 64)
+	return v, err == nil
+}
+
+func (m *MysqlMonitor) globalVariables() map[string]interface{} {
+	values := make(map[string]interface{})
+	m.query("SHOW GLOBAL VARIABLES", func(columns []string, row []sql.NullString) {
+		if value, ok := mysqlValue(row[1]); ok {
+			values["variables."+row[0].String] = value
+		}
+	})
+	return values
+}
+
+var mysqlReplicaTerms = map[string]string{
+	"Replica": "Slave",
+	"Source":  "Master",
+}
+
+func mysqlSlaveColumn(column string) string {
+	words := strings.Split(column, "_")
+	for i, word := range words {
+		if term, ok := mysqlReplicaTerms[word]; ok {
+			words[i] = term
+		}
+	}
+	return strings.Join(words, "_")
+}
+
+func (m *MysqlMonitor) slaveStatus() map[string]interface{} {
+	values := make(map[string]interface{})
+	handle := func(columns []string, row []sql.NullString) {
+		for i, column := range columns {
+			column = mysqlSlaveColumn(column)
+			switch column {
+			case "Slave_IO_Running", "Slave_SQL_Running":
+				if row[i].String == "Yes" {
+					values["slave."+column] = 1.0
+				} else {
+					values["slave."+column] = 0.0
+				}
+				continue
+			}
+			if value, ok := mysqlValue(row[i]); ok {
+				values["slave."+column] = value
+			}
+		}
+	}
+	m.replicaLock.Lock()
+	defer m.replicaLock.Unlock()
+	if m.replicaQuery != "" {
+		m.query(m.replicaQuery, handle)
+		return values
+	}
+	var err error
+	for _, query := range []string{"SHOW REPLICA STATUS", "SHOW SLAVE STATUS"} {
+		err = sqlQuery(m.db, query, handle)
+		if err == nil {
+			m.replicaQuery = query
+			return values
+		}
+	}
+	log.Printf("Error getting monitor values: %s", err)
+	return values
+}
+
+func (m *MysqlMonitor) innodb() map[string]interface{} {
+	values := make(map[string]interface{})
+	status := make(map[string]float64)
+	m.query("SHOW GLOBAL STATUS LIKE 'Innodb_buffer_pool_read%'", func(columns []string, row []sql.NullString) {
+		if value, ok := mysqlValue(row[1]); ok {
+			status[row[0].String] = value
+		}
+	})
+	requests, ok := status["Innodb_buffer_pool_read_requests"]
+	if !ok || requests <= 0 {
+		return values
+	}
+	values["innodb.buffer_pool_hit_ratio"] = 1 - status["Innodb_buffer_pool_reads"]/requests
+	return values
+}
+
+func (m *MysqlMonitor) processlist() map[string]interface{} {
+	values := make(map[string]interface{})
+	total := 0.0
+	m.query("SELECT COMMAND, STATE FROM information_schema.PROCESSLIST", func(columns []string, row []sql.NullString) {
+		state := row[1].String
+		if state == "" {
+			state = "none"
+		}
+		for name, group := range map[string]string{"command": row[0].String, "state": state} {
+			key := "processlist." + name + "." + variableName(strings.ToLower(group))
+			count, _ := values[key].(float64)
+			values[key] = count + 1
+		}
+		total++
+	})
+	values["processlist.total"] = total
+	return values
+}
+
 func (m *MysqlMonitor) GetVariables() []string {
+	variables := []string{}
 	data, err := m.db.Query("SHOW STATUS")
-	defer data.Close()
 	if err != nil {
 		log.Printf("Error getting monitor variables: %s", err)
+		return variables
 	}
-	variables := []string{}
+	defer data.Close()
 	for data.Next() {
 		var name string
 		var value interface{}
 		data.Scan(&name, &value)
 		variables = append(variables, name)
 	}
+	for _, section := range m.sections() {
+		for name := range section() {
+			variables = append(variables, name)
+		}
+	}
 	return variables
 }
 
 func (m *MysqlMonitor) GetValues(names []string) (values map[string]interface{}) {
 	values = make(map[string]interface{})
-	if len(names) == 0 {
-		return
-	}
 	args := []interface{}{}
+	sections := m.sections()
+	sectionValues := make(map[string]map[string]interface{})
 	for _, name := range names {
-		args = append(args, name)
+		found := false
+		for prefix, section := range sections {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			found = true
+			if _, ok := sectionValues[prefix]; !ok {
+				sectionValues[prefix] = section()
+			}
+			if value, ok := sectionValues[prefix][name]; ok {
+				values[name] = value
+			}
+		}
+		if !found {
+			args = append(args, name)
+		}
 	}
-	data, err := m.db.Query("SHOW STATUS WHERE `Variable_name` IN (?"+strings.Repeat(",?", len(names)-1)+")", args...)
-	defer data.Close()
+	if len(args) == 0 {
+		return
+	}
+	data, err := m.db.Query("SHOW STATUS WHERE `Variable_name` IN (?"+strings.Repeat(",?", len(args)-1)+")", args...)
 	if err != nil {
 		log.Printf("Error getting monitor values: %s", err)
+		return
 	}
+	defer data.Close()
 	for data.Next() {
 		var name string
 		var value string
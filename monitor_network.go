@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("network", func(options *json.RawMessage) Monitor {
+		m := &NetworkMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.ProcRoot == "" {
+			m.ProcRoot = "/proc"
+		}
+		m.Start()
+		return m
+	})
+}
+
+var networkFields = map[int]string{
+	0:  "rx_bytes",
+	1:  "rx_packets",
+	2:  "rx_errors",
+	3:  "rx_drops",
+	8:  "tx_bytes",
+	9:  "tx_packets",
+	10: "tx_errors",
+	11: "tx_drops",
+}
+
+type NetworkMonitor struct {
+	ProcRoot   string
+	Interfaces []string
+	prev       *networkStat
+	lock       sync.Mutex
+}
+
+type networkStat struct {
+	time     time.Time
+	counters map[string]uint64
+}
+
+func (m *NetworkMonitor) Start() {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting network variables: %s", err)
+		return
+	}
+	m.prev = stat
+}
+
+func (m *NetworkMonitor) read() (*networkStat, error) {
+	stat := &networkStat{
+		time:     time.Now(),
+		counters: make(map[string]uint64),
+	}
+	data, err := ioutil.ReadFile(filepath.Join(m.ProcRoot, "net/dev"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		iface := strings.TrimSpace(parts[0])
+		if len(m.Interfaces) > 0 && !containsString(m.Interfaces, iface) {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		for i, name := range networkFields {
+			if i < len(fields) {
+				stat.counters[variableName(iface)+"."+name], _ = strconv.ParseUint(fields[i], 10, 64)
+			}
+		}
+	}
+	data, err = ioutil.ReadFile(filepath.Join(m.ProcRoot, "net/snmp"))
+	if err != nil {
+		return stat, nil
+	}
+	var header []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "Tcp:") {
+			continue
+		}
+		fields := strings.Fields(line)[1:]
+		if header == nil {
+			header = fields
+			continue
+		}
+		for i, name := range header {
+			if name == "RetransSegs" && i < len(fields) {
+				stat.counters["tcp.retrans_segs"], _ = strconv.ParseUint(fields[i], 10, 64)
+			}
+		}
+	}
+	return stat, nil
+}
+
+func (m *NetworkMonitor) established() uint64 {
+	count := uint64(0)
+	for _, file := range []string{"net/tcp", "net/tcp6"} {
+		f, err := os.Open(filepath.Join(m.ProcRoot, file))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 3 && fields[3] == "01" {
+				count++
+			}
+		}
+		f.Close()
+	}
+	return count
+}
+
+func (m *NetworkMonitor) GetVariables() []string {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting network variables: %s", err)
+		return []string{}
+	}
+	variables := []string{"tcp.established"}
+	for counter := range stat.counters {
+		variables = append(variables, counter, counter+"_per_sec")
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (m *NetworkMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting network variables: %s", err)
+		return
+	}
+	m.lock.Lock()
+	prev := m.prev
+	if prev == nil || stat.time.Sub(prev.time) >= time.Second {
+		m.prev = stat
+	}
+	m.lock.Unlock()
+
+	for _, name := range names {
+		if name == "tcp.established" {
+			values[name] = m.established()
+			continue
+		}
+		if value, ok := stat.counters[name]; ok {
+			values[name] = value
+			continue
+		}
+		counter := strings.TrimSuffix(name, "_per_sec")
+		value, ok := stat.counters[counter]
+		if !ok || prev == nil || counter == name {
+			continue
+		}
+		last, ok := prev.counters[counter]
+		elapsed := stat.time.Sub(prev.time).Seconds()
+		if !ok || last > value || elapsed <= 0 {
+			continue
+		}
+		values[name] = float64(value-last) / elapsed
+	}
+	return
+}
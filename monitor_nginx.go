@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	AddMonitorDriver("nginx", func(options *json.RawMessage) Monitor {
+		m := &StatusPageMonitor{
+			name:     "nginx",
+			parse:    ParseNginxStatus,
+			counters: []string{"accepts", "handled", "requests"},
+		}
+		json.Unmarshal(*options, &m)
+		if m.URL == "" {
+			m.URL = "http://localhost/nginx_status"
+		}
+		m.Start()
+		return m
+	})
+}
+
+func ParseNginxStatus(status string) map[string]float64 {
+	values := make(map[string]float64)
+	lines := strings.Split(status, "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Active connections:") && len(fields) == 3:
+			if value, err := strconv.ParseFloat(fields[2], 64); err == nil {
+				values["active"] = value
+			}
+		case strings.HasPrefix(line, "server accepts handled requests") && i+1 < len(lines):
+			counts := strings.Fields(lines[i+1])
+			for j, name := range fields[1:] {
+				if j >= len(counts) {
+					break
+				}
+				if value, err := strconv.ParseFloat(counts[j], 64); err == nil {
+					values[name] = value
+				}
+			}
+		case strings.HasPrefix(line, "Reading:"):
+			for j := 0; j+1 < len(fields); j += 2 {
+				name := strings.ToLower(strings.TrimSuffix(fields[j], ":"))
+				if value, err := strconv.ParseFloat(fields[j+1], 64); err == nil {
+					values[name] = value
+				}
+			}
+		}
+	}
+	return values
+}
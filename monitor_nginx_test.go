@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNginxStatus(t *testing.T) {
+	status := "Active connections: 291 \n" +
+		"server accepts handled requests\n" +
+		" 16630948 16630947 31070465 \n" +
+		"Reading: 6 Writing: 179 Waiting: 106 \n"
+	want := map[string]float64{
+		"active":   291,
+		"accepts":  16630948,
+		"handled":  16630947,
+		"requests": 31070465,
+		"reading":  6,
+		"writing":  179,
+		"waiting":  106,
+	}
+	if got := ParseNginxStatus(status); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseNginxStatus = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	_ "github.com/lib/pq"
+)
+
+func init() {
+	AddMonitorDriver("postgres", func(options *json.RawMessage) Monitor {
+		m := &PostgresMonitor{}
+		json.Unmarshal(*options, &m)
+		m.Start()
+		return m
+	})
+}
+
+type PostgresMonitor struct {
+	CachedMonitor
+	Connection string
+	db         *sql.DB
+}
+
+func (m *PostgresMonitor) Start() {
+	m.name = "postgres"
+	m.source = m.fetch
+	var err error
+	m.db, err = sql.Open("postgres", m.Connection)
+	if err != nil {
+		log.Printf("Error opening database: %s", err)
+		return
+	}
+	err = m.db.Ping()
+	if err != nil {
+		log.Printf("Error connecting to database: %s", err)
+	}
+}
+
+func (m *PostgresMonitor) query(query string, handle func(columns []string, row []sql.NullString)) {
+	err := sqlQuery(m.db, query, handle)
+	if err != nil {
+		log.Printf("Error getting postgres variables: %s", err)
+	}
+}
+
+func postgresValue(value sql.NullString) (float64, bool) {
+	if !value.Valid {
+		return 0, false
+	}
+	switch value.String {
+	case "t", "true":
+		return 1, true
+	case "f", "false":
+		return 0, true
+	}
+	v, err := strconv.ParseFloat(value.String, 64)
+	return v, err == nil
+}
+
+func (m *PostgresMonitor) fetch() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if m.db == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	err := sqlQuery(m.db, "SELECT * FROM pg_stat_database WHERE datname IS NOT NULL", func(columns []string, row []sql.NullString) {
+		prefix := ""
+		for i, column := range columns {
+			if column == "datname" {
+				prefix = "database." + variableName(row[i].String) + "."
+			}
+		}
+		for i, column := range columns {
+			if column == "datid" || column == "datname" {
+				continue
+			}
+			if value, ok := postgresValue(row[i]); ok {
+				values[prefix+column] = value
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.query("SELECT datname, pg_database_size(datname) FROM pg_database WHERE datallowconn", func(columns []string, row []sql.NullString) {
+		if value, ok := postgresValue(row[1]); ok {
+			values["database."+variableName(row[0].String)+".size_bytes"] = value
+		}
+	})
+	m.query("SELECT * FROM pg_stat_bgwriter", func(columns []string, row []sql.NullString) {
+		for i, column := range columns {
+			if value, ok := postgresValue(row[i]); ok {
+				values["bgwriter."+column] = value
+			}
+		}
+	})
+	values["connections.total"] = 0.0
+	m.query("SELECT coalesce(state, 'background'), count(*) FROM pg_stat_activity GROUP BY 1", func(columns []string, row []sql.NullString) {
+		if value, ok := postgresValue(row[1]); ok {
+			values["connections."+variableName(row[0].String)] = value
+			values["connections.total"] = values["connections.total"].(float64) + value
+		}
+	})
+	m.query("SHOW max_connections", func(columns []string, row []sql.NullString) {
+		if value, ok := postgresValue(row[0]); ok {
+			values["connections.max"] = value
+		}
+	})
+	m.query("SELECT pg_is_in_recovery(), coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0)", func(columns []string, row []sql.NullString) {
+		if value, ok := postgresValue(row[0]); ok {
+			values["replication.in_recovery"] = value
+		}
+		if value, ok := postgresValue(row[1]); ok {
+			values["replication.replay_delay_seconds"] = value
+		}
+	})
+	values["replication.replicas"] = 0.0
+	m.query(`SELECT application_name, coalesce(extract(epoch FROM replay_lag), 0),
+		coalesce(pg_wal_lsn_diff(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END, replay_lsn), 0)
+		FROM pg_stat_replication`, func(columns []string, row []sql.NullString) {
+		name := "replication." + variableName(row[0].String) + "."
+		if value, ok := postgresValue(row[1]); ok {
+			values[name+"lag_seconds"] = value
+		}
+		if value, ok := postgresValue(row[2]); ok {
+			values[name+"lag_bytes"] = value
+		}
+		values["replication.replicas"] = values["replication.replicas"].(float64) + 1
+	})
+	return values, nil
+}
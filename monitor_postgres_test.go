@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPostgresMonitor(t *testing.T) {
+	connection := os.Getenv("SRVBOT_POSTGRES_DSN")
+	if connection == "" {
+		t.Skip("SRVBOT_POSTGRES_DSN is not set")
+	}
+	m := &PostgresMonitor{Connection: connection}
+	m.Start()
+	values, err := m.fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"connections.total", "connections.max", "replication.in_recovery", "replication.replicas"} {
+		if _, ok := values[name].(float64); !ok {
+			t.Errorf("missing %s in %v", name, values)
+		}
+	}
+	if values["connections.total"].(float64) < 1 {
+		t.Errorf("connections.total = %v, want at least 1", values["connections.total"])
+	}
+}
+
+func TestPostgresMonitorDown(t *testing.T) {
+	if os.Getenv("SRVBOT_POSTGRES_DSN") == "" {
+		t.Skip("SRVBOT_POSTGRES_DSN is not set")
+	}
+	m := &PostgresMonitor{Connection: "postgres://srvbot@127.0.0.1:1/postgres?sslmode=disable&connect_timeout=1"}
+	m.Start()
+	values, err := m.fetch()
+	if err == nil {
+		t.Errorf("fetch from a closed port returned %v, want error", values)
+	}
+	if got := m.GetVariables(); len(got) != 0 {
+		t.Errorf("GetVariables() = %v, want none", got)
+	}
+}
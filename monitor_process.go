@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("process", func(options *json.RawMessage) Monitor {
+		m := &ProcessMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.ProcRoot == "" {
+			m.ProcRoot = "/proc"
+		}
+		m.Start()
+		return m
+	})
+}
+
+const processClockTicks = 100
+
+var processFields = []string{"count", "rss_bytes", "cpu_pct", "fds", "threads", "uptime"}
+
+type ProcessMonitor struct {
+	ProcRoot  string
+	Processes map[string]*ProcessMatcher
+	prev      map[string]*processSample
+	lock      sync.Mutex
+}
+
+type ProcessMatcher struct {
+	Exe     string
+	Cmdline string
+	Pidfile string
+	regex   *regexp.Regexp
+}
+
+type processSample struct {
+	time  time.Time
+	ticks map[int]uint64
+}
+
+type processInfo struct {
+	pid     int
+	ticks   uint64
+	threads uint64
+	start   uint64
+	rss     uint64
+}
+
+func (m *ProcessMonitor) Start() {
+	m.prev = make(map[string]*processSample)
+	for name, matcher := range m.Processes {
+		if matcher.Cmdline == "" {
+			continue
+		}
+		var err error
+		matcher.regex, err = regexp.Compile(matcher.Cmdline)
+		if err != nil {
+			log.Printf("Error compiling regex for process %s: %s", name, err)
+		}
+	}
+}
+
+func (m *ProcessMonitor) GetVariables() []string {
+	names := []string{}
+	for name := range m.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	variables := []string{}
+	for _, name := range names {
+		for _, field := range processFields {
+			variables = append(variables, name+"."+field)
+		}
+	}
+	return variables
+}
+
+func (m *ProcessMonitor) pids() []int {
+	dirs, err := ioutil.ReadDir(m.ProcRoot)
+	if err != nil {
+		log.Printf("Error getting process variables: %s", err)
+		return nil
+	}
+	pids := []int{}
+	for _, dir := range dirs {
+		if pid, err := strconv.Atoi(dir.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+func (m *ProcessMonitor) path(pid int, file string) string {
+	return filepath.Join(m.ProcRoot, strconv.Itoa(pid), file)
+}
+
+func (m *ProcessMonitor) matches(matcher *ProcessMatcher, pid int) bool {
+	if matcher.Exe != "" {
+		exe, err := os.Readlink(m.path(pid, "exe"))
+		if err == nil {
+			exe = filepath.Base(strings.TrimSuffix(exe, " (deleted)"))
+		} else {
+			comm, _ := ioutil.ReadFile(m.path(pid, "comm"))
+			exe = strings.TrimSpace(string(comm))
+		}
+		if exe != matcher.Exe {
+			return false
+		}
+	}
+	if matcher.regex != nil {
+		cmdline, err := ioutil.ReadFile(m.path(pid, "cmdline"))
+		if err != nil {
+			return false
+		}
+		if !matcher.regex.MatchString(strings.TrimSpace(strings.Replace(string(cmdline), "\x00", " ", -1))) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *ProcessMonitor) find(matcher *ProcessMatcher) []int {
+	if matcher.Pidfile != "" {
+		data, err := ioutil.ReadFile(matcher.Pidfile)
+		if err != nil {
+			return nil
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil
+		}
+		if _, err := os.Stat(m.path(pid, "stat")); err != nil || !m.matches(matcher, pid) {
+			return nil
+		}
+		return []int{pid}
+	}
+	if matcher.Exe == "" && matcher.regex == nil {
+		return nil
+	}
+	pids := []int{}
+	for _, pid := range m.pids() {
+		if m.matches(matcher, pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+func (m *ProcessMonitor) info(pid int) (*processInfo, bool) {
+	data, err := ioutil.ReadFile(m.path(pid, "stat"))
+	if err != nil {
+		return nil, false
+	}
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 22 {
+		return nil, false
+	}
+	info := &processInfo{pid: pid}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	info.ticks = utime + stime
+	info.threads, _ = strconv.ParseUint(fields[17], 10, 64)
+	info.start, _ = strconv.ParseUint(fields[19], 10, 64)
+	info.rss, _ = strconv.ParseUint(fields[21], 10, 64)
+	return info, true
+}
+
+func (m *ProcessMonitor) uptime() float64 {
+	data, err := ioutil.ReadFile(filepath.Join(m.ProcRoot, "uptime"))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 1 {
+		return 0
+	}
+	uptime, _ := strconv.ParseFloat(fields[0], 64)
+	return uptime
+}
+
+func (m *ProcessMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		if pos := strings.LastIndex(name, "."); pos >= 0 {
+			wanted[name[:pos]] = true
+		}
+	}
+	uptime := m.uptime()
+	for name, matcher := range m.Processes {
+		if !wanted[name] {
+			continue
+		}
+		sample := &processSample{
+			time:  time.Now(),
+			ticks: make(map[int]uint64),
+		}
+		var rss, threads, fds uint64
+		oldest := uint64(0)
+		for _, pid := range m.find(matcher) {
+			info, ok := m.info(pid)
+			if !ok {
+				continue
+			}
+			sample.ticks[pid] = info.ticks
+			rss += info.rss * uint64(os.Getpagesize())
+			threads += info.threads
+			if oldest == 0 || info.start < oldest {
+				oldest = info.start
+			}
+			if entries, err := ioutil.ReadDir(m.path(pid, "fd")); err == nil {
+				fds += uint64(len(entries))
+			}
+		}
+		all := map[string]interface{}{
+			"count":     uint64(len(sample.ticks)),
+			"rss_bytes": rss,
+			"threads":   threads,
+			"fds":       fds,
+		}
+		if oldest > 0 && uptime > 0 {
+			all["uptime"] = uptime - float64(oldest)/processClockTicks
+		} else {
+			all["uptime"] = 0.0
+		}
+
+		m.lock.Lock()
+		prev := m.prev[name]
+		if prev == nil || sample.time.Sub(prev.time) >= time.Second {
+			m.prev[name] = sample
+		}
+		m.lock.Unlock()
+		if prev != nil {
+			elapsed := sample.time.Sub(prev.time).Seconds()
+			used := uint64(0)
+			for pid, ticks := range sample.ticks {
+				if last, ok := prev.ticks[pid]; ok && ticks >= last {
+					used += ticks - last
+				}
+			}
+			if elapsed > 0 {
+				all["cpu_pct"] = float64(used) / processClockTicks / elapsed * 100
+			}
+		}
+
+		for field, value := range all {
+			values[name+"."+field] = value
+		}
+	}
+	for name := range values {
+		if !containsString(names, name) {
+			delete(values, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	AddMonitorDriver("prometheus", func(options *json.RawMessage) Monitor {
+		m := &PrometheusMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		m.Start()
+		return m
+	})
+}
+
+type PrometheusMonitor struct {
+	CachedMonitor
+	URL     string
+	Timeout int
+	Names   []string
+	Labels  map[string]string
+	names   []*regexp.Regexp
+	labels  map[string]*regexp.Regexp
+	client  *http.Client
+}
+
+type prometheusSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+func (m *PrometheusMonitor) Start() {
+	m.name = "prometheus"
+	m.source = m.fetch
+	m.client = &http.Client{Timeout: time.Second * time.Duration(m.Timeout)}
+	if m.URL == "" {
+		log.Printf("Error starting prometheus monitor: URL is required")
+	}
+	for _, name := range m.Names {
+		regex, err := regexp.Compile("^(?:" + name + ")$")
+		if err != nil {
+			log.Printf("Error compiling prometheus name filter %s: %s", name, err)
+			continue
+		}
+		m.names = append(m.names, regex)
+	}
+	m.labels = make(map[string]*regexp.Regexp)
+	for label, value := range m.Labels {
+		regex, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			log.Printf("Error compiling prometheus label filter %s: %s", label, err)
+			continue
+		}
+		m.labels[label] = regex
+	}
+}
+
+func (sample prometheusSample) Variable() string {
+	if len(sample.labels) == 0 {
+		return sample.name
+	}
+	labels := []string{}
+	for label, value := range sample.labels {
+		labels = append(labels, label+"="+strconv.Quote(value))
+	}
+	sort.Strings(labels)
+	return sample.name + "{" + strings.Join(labels, ",") + "}"
+}
+
+func parsePrometheusLabels(text string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		text = strings.TrimLeft(text, " ,")
+		if strings.HasPrefix(text, "}") {
+			return labels, text[1:], nil
+		}
+		eq := strings.Index(text, "=")
+		if eq < 0 {
+			return nil, "", fmt.Errorf("missing = in labels")
+		}
+		label := strings.TrimSpace(text[:eq])
+		text = strings.TrimLeft(text[eq+1:], " ")
+		if !strings.HasPrefix(text, `"`) {
+			return nil, "", fmt.Errorf("missing quote in labels")
+		}
+		value := []byte{}
+		i := 1
+		for ; i < len(text) && text[i] != '"'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+				if text[i] == 'n' {
+					value = append(value, '\n')
+					continue
+				}
+			}
+			value = append(value, text[i])
+		}
+		if i >= len(text) {
+			return nil, "", fmt.Errorf("unterminated label value")
+		}
+		labels[label] = string(value)
+		text = text[i+1:]
+	}
+}
+
+func ParsePrometheus(r io.Reader) ([]prometheusSample, error) {
+	samples := []prometheusSample{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample := prometheusSample{}
+		rest := ""
+		if brace := strings.Index(line, "{"); brace >= 0 {
+			sample.name = line[:brace]
+			var err error
+			sample.labels, rest, err = parsePrometheusLabels(line[brace+1:])
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing %s: %s", line, err)
+			}
+		} else {
+			space := strings.IndexAny(line, " \t")
+			if space < 0 {
+				continue
+			}
+			sample.name = line[:space]
+			rest = line[space:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 1 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		sample.value = value
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+func (m *PrometheusMonitor) filtered(sample prometheusSample) bool {
+	if len(m.names) > 0 {
+		found := false
+		for _, regex := range m.names {
+			if regex.MatchString(sample.name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for label, regex := range m.labels {
+		if !regex.MatchString(sample.labels[label]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *PrometheusMonitor) fetch() (map[string]interface{}, error) {
+	if m.URL == "" {
+		return nil, fmt.Errorf("no URL configured")
+	}
+	resp, err := m.client.Get(m.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	samples, err := ParsePrometheus(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	for _, sample := range samples {
+		if m.filtered(sample) {
+			values[sample.Variable()] = sample.value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParsePrometheus(t *testing.T) {
+	text := `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{ method = "get", code="400" , } 3
+msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9
+metric_without_timestamp_and_labels 12.47
+go_gc_pause NaN
+`
+	samples, err := ParsePrometheus(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		`http_requests_total{code="200",method="post"}`:                                                    1027,
+		`http_requests_total{code="400",method="get"}`:                                                     3,
+		`msdos_file_access_time_seconds{error="Cannot find file:\n\"FILE.TXT\"",path="C:\\DIR\\FILE.TXT"}`: 1.458255915e9,
+		`metric_without_timestamp_and_labels`:                                                              12.47,
+	}
+	got := make(map[string]float64)
+	for _, sample := range samples {
+		got[sample.Variable()] = sample.value
+	}
+	if len(got) != len(want)+1 {
+		t.Errorf("ParsePrometheus returned %d samples, want %d", len(got), len(want)+1)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+	if !math.IsNaN(got["go_gc_pause"]) {
+		t.Errorf("go_gc_pause = %v, want NaN", got["go_gc_pause"])
+	}
+
+	if _, err := ParsePrometheus(strings.NewReader(`broken{label="value} 1`)); err == nil {
+		t.Errorf("ParsePrometheus with an unterminated label succeeded, want error")
+	}
+}
+
+func TestPrometheusFilters(t *testing.T) {
+	m := &PrometheusMonitor{
+		Names:  []string{"http_.*"},
+		Labels: map[string]string{"code": "2.."},
+	}
+	m.Start()
+	tests := []struct {
+		sample prometheusSample
+		want   bool
+	}{
+		{prometheusSample{name: "http_requests_total", labels: map[string]string{"code": "200"}}, true},
+		{prometheusSample{name: "http_requests_total", labels: map[string]string{"code": "400"}}, false},
+		{prometheusSample{name: "http_requests_total"}, false},
+		{prometheusSample{name: "go_gc_pause", labels: map[string]string{"code": "200"}}, false},
+	}
+	for _, test := range tests {
+		if got := m.filtered(test.sample); got != test.want {
+			t.Errorf("filtered(%s) = %v, want %v", test.sample.Variable(), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	AddMonitorDriver("redis", func(options *json.RawMessage) Monitor {
+		m := &RedisMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.Address == "" {
+			m.Address = "localhost:6379"
+		}
+		if m.Timeout <= 0 {
+			m.Timeout = 10
+		}
+		m.Start()
+		return m
+	})
+}
+
+type RedisMonitor struct {
+	CachedMonitor
+	Address  string
+	Password string
+	Timeout  int
+}
+
+func (m *RedisMonitor) Start() {
+	m.name = "redis"
+	m.source = m.fetch
+}
+
+func redisCommand(args ...string) string {
+	command := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		command += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return command
+}
+
+func redisReply(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("%s", line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", err
+		}
+		if size < 0 {
+			return "", nil
+		}
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return "", err
+		}
+		return string(data[:size]), nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
+
+func ParseRedisInfo(info string) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := variableName(parts[0]), parts[1]
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			values[name] = v
+			continue
+		}
+		if !strings.Contains(value, "=") {
+			continue
+		}
+		if strings.HasPrefix(name, "db") {
+			name = "keyspace." + name
+		}
+		for _, field := range strings.Split(value, ",") {
+			fieldParts := strings.SplitN(field, "=", 2)
+			if len(fieldParts) != 2 {
+				continue
+			}
+			if v, err := strconv.ParseFloat(fieldParts[1], 64); err == nil {
+				values[name+"."+variableName(fieldParts[0])] = v
+			}
+		}
+	}
+	return values
+}
+
+func (m *RedisMonitor) fetch() (map[string]interface{}, error) {
+	conn, err := dialAddress(m.Address, m.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	if m.Password != "" {
+		_, err = io.WriteString(conn, redisCommand("AUTH", m.Password))
+		if err != nil {
+			return nil, err
+		}
+		_, err = redisReply(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = io.WriteString(conn, redisCommand("INFO"))
+	if err != nil {
+		return nil, err
+	}
+	info, err := redisReply(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRedisInfo(info), nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseRedisInfo(t *testing.T) {
+	info := "# Server\r\n" +
+		"redis_version:7.0.11\r\n" +
+		"uptime_in_seconds:100\r\n" +
+		"\r\n" +
+		"# Clients\r\n" +
+		"connected_clients:3\r\n" +
+		"# Memory\r\n" +
+		"used_memory:1024\r\n" +
+		"mem_fragmentation_ratio:1.5\r\n" +
+		"# Stats\r\n" +
+		"keyspace_hits:40\r\n" +
+		"keyspace_misses:2\r\n" +
+		"evicted_keys:0\r\n" +
+		"# Commandstats\r\n" +
+		"cmdstat_get:calls=21,usec=175,usec_per_call=8.33\r\n" +
+		"cmdstat_client|list:calls=2,usec=30\r\n" +
+		"# Keyspace\r\n" +
+		"db0:keys=10,expires=2,avg_ttl=300\r\n" +
+		"db1:keys=1,expires=0,avg_ttl=0\r\n"
+	want := map[string]interface{}{
+		"uptime_in_seconds":         100.0,
+		"connected_clients":         3.0,
+		"used_memory":               1024.0,
+		"mem_fragmentation_ratio":   1.5,
+		"keyspace_hits":             40.0,
+		"keyspace_misses":           2.0,
+		"evicted_keys":              0.0,
+		"cmdstat_get.calls":         21.0,
+		"cmdstat_get.usec":          175.0,
+		"cmdstat_get.usec_per_call": 8.33,
+		"cmdstat_client_list.calls": 2.0,
+		"cmdstat_client_list.usec":  30.0,
+		"keyspace.db0.keys":         10.0,
+		"keyspace.db0.expires":      2.0,
+		"keyspace.db0.avg_ttl":      300.0,
+		"keyspace.db1.keys":         1.0,
+		"keyspace.db1.expires":      0.0,
+		"keyspace.db1.avg_ttl":      0.0,
+	}
+	if got := ParseRedisInfo(info); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRedisInfo = %v, want %v", got, want)
+	}
+}
+
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	var count int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &count); err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		var length int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &length); err != nil {
+			return nil, err
+		}
+		arg := make([]byte, length+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		if string(arg[length:]) != "\r\n" {
+			return nil, fmt.Errorf("argument %q is not terminated by CRLF", arg)
+		}
+		args[i] = string(arg[:length])
+	}
+	return args, nil
+}
+
+func TestRedisMonitorFetch(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	info := "# Server\r\nuptime_in_seconds:100\r\n# Keyspace\r\ndb0:keys=10,expires=2\r\n"
+	commands := make(chan []string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readRedisCommand(r)
+			if err != nil {
+				close(commands)
+				return
+			}
+			commands <- args
+			switch args[0] {
+			case "AUTH":
+				io.WriteString(conn, "+OK\r\n")
+			case "INFO":
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(info), info)
+			}
+		}
+	}()
+	m := &RedisMonitor{Address: listener.Addr().String(), Password: "secret", Timeout: 5}
+	m.Start()
+	got, err := m.fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"uptime_in_seconds":    100.0,
+		"keyspace.db0.keys":    10.0,
+		"keyspace.db0.expires": 2.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetch = %v, want %v", got, want)
+	}
+	for _, want := range [][]string{{"AUTH", "secret"}, {"INFO"}} {
+		if args := <-commands; !reflect.DeepEqual(args, want) {
+			t.Errorf("server received %q, want %q", args, want)
+		}
+	}
+}
+
+func TestRedisMonitorFetchAuthError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := readRedisCommand(bufio.NewReader(conn)); err == nil {
+			io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+		}
+	}()
+	m := &RedisMonitor{Address: listener.Addr().String(), Password: "wrong", Timeout: 5}
+	m.Start()
+	if values, err := m.fetch(); err == nil {
+		t.Errorf("fetch = %v, want error", values)
+	}
+}
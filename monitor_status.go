@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type StatusPageMonitor struct {
+	URL      string
+	Timeout  int
+	name     string
+	parse    func(string) map[string]float64
+	counters []string
+	client   *http.Client
+	prev     *statusPageStat
+	lock     sync.Mutex
+}
+
+type statusPageStat struct {
+	time   time.Time
+	values map[string]float64
+}
+
+func (m *StatusPageMonitor) Start() {
+	if m.Timeout <= 0 {
+		m.Timeout = 10
+	}
+	m.client = &http.Client{Timeout: time.Second * time.Duration(m.Timeout)}
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting %s variables: %s", m.name, err)
+		return
+	}
+	m.prev = stat
+}
+
+func (m *StatusPageMonitor) read() (*statusPageStat, error) {
+	resp, err := m.client.Get(m.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s returned %s", m.URL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &statusPageStat{
+		time:   time.Now(),
+		values: m.parse(string(body)),
+	}, nil
+}
+
+func (m *StatusPageMonitor) GetVariables() []string {
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting %s variables: %s", m.name, err)
+		return []string{}
+	}
+	variables := []string{}
+	for name := range stat.values {
+		variables = append(variables, name)
+		if containsString(m.counters, name) {
+			variables = append(variables, name+"_per_sec")
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (m *StatusPageMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	stat, err := m.read()
+	if err != nil {
+		log.Printf("Error getting %s variables: %s", m.name, err)
+		return
+	}
+	m.lock.Lock()
+	prev := m.prev
+	if prev == nil || stat.time.Sub(prev.time) >= time.Second {
+		m.prev = stat
+	}
+	m.lock.Unlock()
+
+	for _, name := range names {
+		if value, ok := stat.values[name]; ok {
+			values[name] = value
+			continue
+		}
+		counter := strings.TrimSuffix(name, "_per_sec")
+		value, ok := stat.values[counter]
+		if !ok || prev == nil || counter == name || !containsString(m.counters, counter) {
+			continue
+		}
+		last, ok := prev.values[counter]
+		elapsed := stat.time.Sub(prev.time).Seconds()
+		if !ok || last > value || elapsed <= 0 {
+			continue
+		}
+		values[name] = (value - last) / elapsed
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	AddMonitorDriver("system", func(options *json.RawMessage) Monitor {
+		m := &SystemMonitor{}
+		json.Unmarshal(*options, &m)
+		if m.ProcRoot == "" {
+			m.ProcRoot = "/proc"
+		}
+		m.Start()
+		return m
+	})
+}
+
+type SystemMonitor struct {
+	ProcRoot string
+}
+
+func (m *SystemMonitor) Start() {
+
+}
+
+func (m *SystemMonitor) GetVariables() []string {
+	return []string{"load1", "load5", "load15", "procs_running", "procs_total", "uptime", "files_open", "files_max", "entropy"}
+}
+
+func (m *SystemMonitor) readFields(file string) []string {
+	data, err := ioutil.ReadFile(filepath.Join(m.ProcRoot, file))
+	if err != nil {
+		log.Printf("Error getting system variables: %s", err)
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+func (m *SystemMonitor) GetValues(names []string) (values map[string]interface{}) {
+	values = make(map[string]interface{})
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	if wanted["load1"] || wanted["load5"] || wanted["load15"] || wanted["procs_running"] || wanted["procs_total"] {
+		fields := m.readFields("loadavg")
+		if len(fields) >= 4 {
+			values["load1"], _ = strconv.ParseFloat(fields[0], 64)
+			values["load5"], _ = strconv.ParseFloat(fields[1], 64)
+			values["load15"], _ = strconv.ParseFloat(fields[2], 64)
+			procs := strings.Split(fields[3], "/")
+			if len(procs) == 2 {
+				values["procs_running"], _ = strconv.ParseUint(procs[0], 10, 64)
+				values["procs_total"], _ = strconv.ParseUint(procs[1], 10, 64)
+			}
+		}
+	}
+	if wanted["uptime"] {
+		fields := m.readFields("uptime")
+		if len(fields) >= 1 {
+			values["uptime"], _ = strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	if wanted["files_open"] || wanted["files_max"] {
+		fields := m.readFields("sys/fs/file-nr")
+		if len(fields) >= 3 {
+			allocated, _ := strconv.ParseUint(fields[0], 10, 64)
+			free, _ := strconv.ParseUint(fields[1], 10, 64)
+			values["files_open"] = allocated - free
+			values["files_max"], _ = strconv.ParseUint(fields[2], 10, 64)
+		}
+	}
+	if wanted["entropy"] {
+		fields := m.readFields("sys/kernel/random/entropy_avail")
+		if len(fields) >= 1 {
+			values["entropy"], _ = strconv.ParseUint(fields[0], 10, 64)
+		}
+	}
+	for name := range values {
+		if !wanted[name] {
+			delete(values, name)
+		}
+	}
+	return
+}
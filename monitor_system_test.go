@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemMonitor(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"loadavg":                         "0.52 0.58 0.59 3/812 12345\n",
+		"uptime":                          "350735.47 234388.90\n",
+		"sys/fs/file-nr":                  "9024\t24\t9223372036854775807\n",
+		"sys/kernel/random/entropy_avail": "256\n",
+	}
+	for file, data := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &SystemMonitor{ProcRoot: root}
+	values := m.GetValues(m.GetVariables())
+	want := map[string]interface{}{
+		"load1":         0.52,
+		"load5":         0.58,
+		"load15":        0.59,
+		"procs_running": uint64(3),
+		"procs_total":   uint64(812),
+		"uptime":        350735.47,
+		"files_open":    uint64(9000),
+		"files_max":     uint64(9223372036854775807),
+		"entropy":       uint64(256),
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %v, want %v", name, values[name], value)
+		}
+	}
+	values = m.GetValues([]string{"uptime"})
+	if len(values) != 1 {
+		t.Errorf("GetValues(uptime) = %v, want only uptime", values)
+	}
+}
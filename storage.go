@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type StorageConfig struct {
+	Path      string
+	Retention string
+	Tiers     []StorageTierConfig
+}
+
+type StorageTierConfig struct {
+	Resolution string
+	Retention  string
+}
+
+type Storage struct {
+	Path      string
+	Retention time.Duration
+	Tiers     []*StorageTier
+	buckets   map[string]*storageBucket
+	lock      sync.Mutex
+}
+
+type StorageTier struct {
+	Resolution time.Duration
+	Retention  time.Duration
+}
+
+type storageBucket struct {
+	MonitorRollup
+	sum float64
+}
+
+type storageTiers []*StorageTier
+
+func (t storageTiers) Len() int           { return len(t) }
+func (t storageTiers) Less(i, j int) bool { return t[i].Resolution < t[j].Resolution }
+func (t storageTiers) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+type storageSettings struct {
+	Interval int
+	Track    map[string]int
+}
+
+var storage *Storage
+
+func newStorage(config *StorageConfig) (*Storage, error) {
+	s := &Storage{
+		Path:      config.Path,
+		Retention: 24 * time.Hour,
+		buckets:   make(map[string]*storageBucket),
+	}
+	if s.Path == "" {
+		s.Path = "srvbot.data"
+	}
+	if config.Retention != "" {
+		var err error
+		s.Retention, err = ParseSpan(config.Retention)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, tierConfig := range config.Tiers {
+		resolution, err := ParseSpan(tierConfig.Resolution)
+		if err != nil {
+			return nil, err
+		}
+		retention, err := ParseSpan(tierConfig.Retention)
+		if err != nil {
+			return nil, err
+		}
+		if resolution <= 0 {
+			return nil, fmt.Errorf("Storage tier resolution must be positive")
+		}
+		s.Tiers = append(s.Tiers, &StorageTier{
+			Resolution: resolution,
+			Retention:  retention,
+		})
+	}
+	sort.Sort(storageTiers(s.Tiers))
+	err := os.MkdirAll(s.Path, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Storage) Start() {
+	go func() {
+		for {
+			s.Compact()
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+func (s *Storage) dir(monitor string) string {
+	return filepath.Join(s.Path, url.PathEscape(monitor))
+}
+
+func (s *Storage) file(monitor string, variable string) string {
+	return filepath.Join(s.dir(monitor), url.PathEscape(variable)+".log")
+}
+
+func (s *Storage) tierFile(monitor string, variable string, tier *StorageTier) string {
+	return filepath.Join(s.dir(monitor), tier.Resolution.String(), url.PathEscape(variable)+".log")
+}
+
+func (s *Storage) appendLine(file string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (s *Storage) SaveSettings(monitor string, mt *MonitorTrack) error {
+	settings := storageSettings{
+		Interval: mt.Interval,
+		Track:    make(map[string]int),
+	}
+	for variable, vt := range mt.Variables {
+		settings.Track[variable] = vt.History
+	}
+	data, err := json.MarshalIndent(settings, "", "\t")
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err = os.MkdirAll(s.dir(monitor), 0755)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir(monitor), "settings.json.tmp")
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir(monitor), "settings.json"))
+}
+
+func (s *Storage) Append(monitor string, variable string, sample MonitorSample) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := s.appendLine(s.file(monitor, variable), sample)
+	if err != nil {
+		return err
+	}
+	point, ok := sample.Rollup()
+	if !ok {
+		return nil
+	}
+	for _, tier := range s.Tiers {
+		key := s.tierFile(monitor, variable, tier)
+		start := point.Time.Truncate(tier.Resolution)
+		bucket, ok := s.buckets[key]
+		if ok && !bucket.Time.Equal(start) {
+			err = s.appendLine(key, bucket.MonitorRollup)
+			if err != nil {
+				return err
+			}
+			ok = false
+		}
+		if !ok {
+			bucket = &storageBucket{
+				MonitorRollup: MonitorRollup{
+					Time: start,
+					Min:  point.Min,
+					Max:  point.Max,
+				},
+			}
+			s.buckets[key] = bucket
+		}
+		bucket.sum += point.Avg
+		bucket.Count++
+		bucket.Avg = bucket.sum / float64(bucket.Count)
+		bucket.Min = math.Min(bucket.Min, point.Min)
+		bucket.Max = math.Max(bucket.Max, point.Max)
+	}
+	return nil
+}
+
+func (s *Storage) Remove(monitor string, variable string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	files := []string{s.file(monitor, variable)}
+	for _, tier := range s.Tiers {
+		files = append(files, s.tierFile(monitor, variable, tier))
+		delete(s.buckets, s.tierFile(monitor, variable, tier))
+	}
+	for _, file := range files {
+		err := os.Remove(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) readSamples(file string, since time.Time) ([]MonitorSample, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	samples := []MonitorSample{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample MonitorSample
+		if json.Unmarshal(scanner.Bytes(), &sample) != nil {
+			continue
+		}
+		if sample.Time.Before(since) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+func (s *Storage) readRollups(file string, since time.Time) ([]MonitorRollup, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	points := []MonitorRollup{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var point MonitorRollup
+		if json.Unmarshal(scanner.Bytes(), &point) != nil {
+			continue
+		}
+		if point.Time.Before(since) {
+			continue
+		}
+		points = append(points, point)
+	}
+	return points, scanner.Err()
+}
+
+func (s *Storage) Query(monitor string, variable string, since time.Time) ([]MonitorRollup, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	span := time.Since(since)
+	if len(s.Tiers) == 0 || span <= s.Retention {
+		samples, err := s.readSamples(s.file(monitor, variable), since)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		points := []MonitorRollup{}
+		for _, sample := range samples {
+			if point, ok := sample.Rollup(); ok {
+				points = append(points, point)
+			}
+		}
+		return points, nil
+	}
+	tier := s.Tiers[len(s.Tiers)-1]
+	for _, t := range s.Tiers {
+		if t.Retention >= span {
+			tier = t
+			break
+		}
+	}
+	points, err := s.readRollups(s.tierFile(monitor, variable, tier), since)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if bucket, ok := s.buckets[s.tierFile(monitor, variable, tier)]; ok && !bucket.Time.Before(since) {
+		points = append(points, bucket.MonitorRollup)
+	}
+	return points, nil
+}
+
+func (s *Storage) Load(monitor string, mt *MonitorTrack) error {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir(monitor), "settings.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	settings := storageSettings{}
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return err
+	}
+	mt.Interval = settings.Interval
+	since := time.Now().Add(-s.Retention)
+	for variable, history := range settings.Track {
+		if history <= 0 {
+			continue
+		}
+		vt := &MonitorTrackVariable{History: history}
+		s.lock.Lock()
+		vt.Data, err = s.readSamples(s.file(monitor, variable), since)
+		s.lock.Unlock()
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Error loading history for %s.%s: %s", monitor, variable, err)
+		}
+		if len(vt.Data) > history {
+			vt.Data = vt.Data[len(vt.Data)-history:]
+		}
+		mt.Variables[variable] = vt
+	}
+	return nil
+}
+
+func (s *Storage) Compact() {
+	s.compactFiles(filepath.Join(s.Path, "*", "*.log"), s.Retention)
+	for _, tier := range s.Tiers {
+		s.compactFiles(filepath.Join(s.Path, "*", tier.Resolution.String(), "*.log"), tier.Retention)
+	}
+}
+
+func (s *Storage) compactFiles(pattern string, retention time.Duration) {
+	since := time.Now().Add(-retention)
+	files, _ := filepath.Glob(pattern)
+	for _, file := range files {
+		err := s.compactFile(file, since)
+		if err != nil {
+			log.Printf("Error compacting %s: %s", file, err)
+		}
+	}
+}
+
+func (s *Storage) compactFile(file string, since time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		var entry struct {
+			Time time.Time
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil || entry.Time.Before(since) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return os.Remove(file)
+	}
+	tmp := file + ".tmp"
+	err = ioutil.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}